@@ -1,12 +1,331 @@
 package args
 
+import (
+	"errors"
+	"flag"
+	"math"
+	"strings"
+)
+
 type Args struct {
 	FilterOnlyRingedSource                   bool
+	MissionRadiusLy                          float64    // distance within which stations hand out missions for the target
+	TargetGovernments                        StringList // factions with one of these governments are targets, all others give missions
 	MinSourceSystemCount                     int
 	MaxOtherDestSystemsForSource             int
-	MaxOtherDestSystemsForSourceAnarchyCount int
+	MaxOtherDestSystemsForSourceAnarchyCount int // counts target factions, the name predates TargetGovernments
 	MinSourceStationCount                    int
 	MaxDistanceInLsForStationToBeConsidered  int
-	ConsiderGroundBases						 bool
-	ConsiderOdysseySettlements				 bool
+	ConsiderGroundBases                      bool
+	ConsiderOdysseySettlements               bool
+	RequireLargePads                         bool
+	AllowedStationTypes                      StringList
+	RequiredServices                         StringList
+	IncludeFleetCarriers                     bool
+	IncludeMegaships                         bool
+	ExcludeTargetStates                      StringList
+	MinTargetPopulation                      int64
+	MaxTargetPopulation                      int64 // 0 means no limit
+	TargetAllegiances                        StringList
+	TargetEconomies                          StringList // primary economy of the target system, empty for any
+	TargetSystemGovernments                  StringList // government of the target system, unlike TargetGovernments not of its factions
+	ExcludeTargetPowers                      StringList
+	RingTypeSuitability                      WeightMap // RES suitability 0..1 per ring type
+	GasGiantRingFactor                       float64   // multiplies the suitability of rings around gas giants
+	MinRingSuitability                       float64   // rings below are not considered suitable
+	RingDistanceFalloffLs                    float64   // distance at which the ring bonus is halved
+	Scorer                                   string    // scoring formula, one of KnownScorers
+	FactionBaseScore                         float64   // a source faction in n systems scores FactionBaseScore - FactionCountDecay/n
+	FactionCountDecay                        float64
+	RingBonusWeight                          float64           // score of a perfectly suitable ring at arrival
+	OutsideTargetPenalty                     float64           // per squared target faction outside the radius
+	OutsideSystemPenalty                     float64           // per destination system outside the radius
+	InsideTargetPenalty                      float64           // per target faction in the source systems
+	WeightGiversByStations                   bool              // count a giver faction once per eligible station instead of once per system
+	MissionsPerBoard                         float64           // massacre missions a giver faction is expected to offer per counted mission board
+	KillsPerMission                          float64           // average kills a massacre mission asks for
+	MissionCap                               int               // missions a player can hold at once
+	RewardBrackets                           RewardBracketList // solo reward ranges by kill count, config file only
+	WingMissions                             bool              // plan with wing missions instead of solo ones
+	WingRewardFactor                         float64           // wing missions pay this multiple of a solo mission
+	ReputationTier                           string            // reputation with the giver factions
+	ReputationRewardFactors                  WeightMap         // reward factor per reputation tier
+	KillsPerHour                             float64           // assumed kill rate in the RES
+	MassacreMissionProbability               float64           // chance of a massacre mission per giver faction and board refresh, for simulate
+	FactionMissionProbabilities              WeightMap         // overrides MassacreMissionProbability for single factions
+	BoardRefreshMinutes                      float64
+	TravelMinutesPerStation                  float64   // time to get from one mission board to the next
+	MaxSimulatedHours                        float64   // a simulated run gives up after this time
+	MultiTargetFactions                      bool      // evaluate every target faction of a system as its own target
+	SiblingTargetPenalty                     float64   // per other target faction in the target system, weighted by relative influence
+	EconomyBonus                             WeightMap // added to the score by the primary economy of the target system, negative to avoid one
+	GovernmentBonus                          WeightMap // by the government of the target system
+	PowerBonus                               WeightMap // by the power controlling the target system
+	PopulationWeight                         float64   // added per order of magnitude (log10) of the target population
+}
+
+// Default returns the built-in configuration, which config files and flags are applied on top of.
+func Default() Args {
+	return Args{
+		FilterOnlyRingedSource:                   true,
+		MissionRadiusLy:                          10,
+		TargetGovernments:                        StringList{"Anarchy"},
+		MinSourceSystemCount:                     3,
+		MaxOtherDestSystemsForSource:             0,
+		MaxOtherDestSystemsForSourceAnarchyCount: 0,
+		MinSourceStationCount:                    6,
+		MaxDistanceInLsForStationToBeConsidered:  1000,
+		ConsiderGroundBases:                      false,
+		ConsiderOdysseySettlements:               false,
+		RequireLargePads:                         false,
+		AllowedStationTypes:                      StringList{StationOutpost, StationCoriolis, StationOrbis, StationOcellus},
+		RequiredServices:                         StringList{ServiceMissions},
+		IncludeFleetCarriers:                     false,
+		IncludeMegaships:                         false,
+		ExcludeTargetStates:                      StringList{},
+		MinTargetPopulation:                      0,
+		MaxTargetPopulation:                      0,
+		TargetAllegiances:                        StringList{},
+		TargetEconomies:                          StringList{},
+		TargetSystemGovernments:                  StringList{},
+		ExcludeTargetPowers:                      StringList{},
+		RingTypeSuitability:                      WeightMap{"Metallic": 1, "Metal Rich": 1, "Rocky": 0.8, "Icy": 0.6},
+		GasGiantRingFactor:                       1.2,
+		MinRingSuitability:                       0.5,
+		RingDistanceFalloffLs:                    5000,
+		Scorer:                                   ScorerDefault,
+		FactionBaseScore:                         2,
+		FactionCountDecay:                        1,
+		RingBonusWeight:                          2,
+		OutsideTargetPenalty:                     1,
+		OutsideSystemPenalty:                     1,
+		InsideTargetPenalty:                      1,
+		WeightGiversByStations:                   false,
+		MissionsPerBoard:                         2,
+		KillsPerMission:                          24,
+		MissionCap:                               20,
+		RewardBrackets:                           append(RewardBracketList(nil), DefaultRewardBrackets...),
+		WingMissions:                             false,
+		WingRewardFactor:                         2.5,
+		ReputationTier:                           ReputationAllied,
+		ReputationRewardFactors:                  WeightMap{ReputationAllied: 1, ReputationFriendly: 0.85, ReputationCordial: 0.7, ReputationNeutral: 0.6, ReputationUnfriendly: 0.5, ReputationHostile: 0.4},
+		KillsPerHour:                             30,
+		MassacreMissionProbability:               0.2,
+		FactionMissionProbabilities:              WeightMap{},
+		BoardRefreshMinutes:                      10,
+		TravelMinutesPerStation:                  3,
+		MaxSimulatedHours:                        12,
+		MultiTargetFactions:                      false,
+		SiblingTargetPenalty:                     3,
+		EconomyBonus:                             WeightMap{},
+		GovernmentBonus:                          WeightMap{},
+		PowerBonus:                               WeightMap{},
+		PopulationWeight:                         0,
+	}
+}
+
+// RegisterFlags binds every field of a to a flag on fs, using the current values as defaults.
+func (a *Args) RegisterFlags(fs *flag.FlagSet) {
+	fs.BoolVar(&a.FilterOnlyRingedSource, "only-ringed", a.FilterOnlyRingedSource, "only consider target systems that have rings")
+	fs.Float64Var(&a.MissionRadiusLy, "radius", a.MissionRadiusLy, "radius in ly around the target within which source systems give missions")
+	fs.Var(&a.TargetGovernments, "target-governments", "comma separated governments of the factions massacre missions are against")
+	fs.IntVar(&a.MinSourceSystemCount, "min-source-systems", a.MinSourceSystemCount, "minimum number of populated systems around the target")
+	fs.IntVar(&a.MaxOtherDestSystemsForSource, "max-outside-systems", a.MaxOtherDestSystemsForSource, "maximum number of other destination systems reachable from the source systems")
+	fs.IntVar(&a.MaxOtherDestSystemsForSourceAnarchyCount, "max-outside-targets", a.MaxOtherDestSystemsForSourceAnarchyCount, "maximum number of target factions in those other destination systems")
+	fs.IntVar(&a.MaxOtherDestSystemsForSourceAnarchyCount, "max-outside-anarchy", a.MaxOtherDestSystemsForSourceAnarchyCount, "deprecated name of -max-outside-targets")
+	fs.IntVar(&a.MinSourceStationCount, "min-source-stations", a.MinSourceStationCount, "minimum number of eligible stations in the source systems")
+	fs.IntVar(&a.MaxDistanceInLsForStationToBeConsidered, "max-station-distance", a.MaxDistanceInLsForStationToBeConsidered, "maximum distance in Ls from arrival for a station to be considered")
+	fs.BoolVar(&a.ConsiderGroundBases, "ground-bases", a.ConsiderGroundBases, "also consider planetary outposts and ports")
+	fs.BoolVar(&a.ConsiderOdysseySettlements, "odyssey-settlements", a.ConsiderOdysseySettlements, "also consider Odyssey settlements")
+	fs.BoolVar(&a.RequireLargePads, "large-pads", a.RequireLargePads, "only consider stations with a large landing pad")
+	fs.Var(&a.AllowedStationTypes, "station-types", "comma separated station types a mission-source station may have")
+	fs.Var(&a.RequiredServices, "services", "comma separated services a mission-source station must offer")
+	fs.BoolVar(&a.IncludeFleetCarriers, "fleet-carriers", a.IncludeFleetCarriers, "also consider fleet carriers")
+	fs.BoolVar(&a.IncludeMegaships, "megaships", a.IncludeMegaships, "also consider megaships")
+	fs.Var(&a.ExcludeTargetStates, "exclude-target-states", "comma separated faction states (e.g. Retreat,War) that disqualify the target faction")
+	fs.Int64Var(&a.MinTargetPopulation, "min-target-population", a.MinTargetPopulation, "minimum population of the target system")
+	fs.Int64Var(&a.MaxTargetPopulation, "max-target-population", a.MaxTargetPopulation, "maximum population of the target system, 0 for no limit")
+	fs.Var(&a.TargetAllegiances, "target-allegiances", "comma separated system allegiances the target system may have, empty for any")
+	fs.Var(&a.TargetEconomies, "target-economies", "comma separated primary economies the target system may have, empty for any")
+	fs.Var(&a.TargetSystemGovernments, "target-system-governments", "comma separated governments the target system may have, empty for any")
+	fs.Var(&a.ExcludeTargetPowers, "exclude-target-powers", "comma separated powers whose controlled or exploited systems are skipped as targets")
+	fs.Var(&a.RingTypeSuitability, "ring-suitability", "RES suitability per ring type, e.g. \"Metallic=1,Rocky=0.8,Icy=0.6\"")
+	fs.Float64Var(&a.GasGiantRingFactor, "gas-giant-ring-factor", a.GasGiantRingFactor, "factor applied to the suitability of rings around gas giants")
+	fs.Float64Var(&a.MinRingSuitability, "min-ring-suitability", a.MinRingSuitability, "minimum suitability for a ring to count as RES site")
+	fs.Float64Var(&a.RingDistanceFalloffLs, "ring-distance-falloff", a.RingDistanceFalloffLs, "distance in Ls from arrival at which the ring bonus is halved")
+	fs.StringVar(&a.Scorer, "scorer", a.Scorer, "scoring formula: "+strings.Join(KnownScorers, ", "))
+	fs.Float64Var(&a.FactionBaseScore, "faction-base-score", a.FactionBaseScore, "score of a source faction before the decay")
+	fs.Float64Var(&a.FactionCountDecay, "faction-count-decay", a.FactionCountDecay, "subtracted from the faction score, divided by the number of source systems of the faction")
+	fs.Float64Var(&a.RingBonusWeight, "ring-bonus-weight", a.RingBonusWeight, "score of a perfectly suitable ring at arrival")
+	fs.Float64Var(&a.OutsideTargetPenalty, "outside-target-penalty", a.OutsideTargetPenalty, "penalty per squared target faction outside the radius")
+	fs.Float64Var(&a.OutsideSystemPenalty, "outside-system-penalty", a.OutsideSystemPenalty, "penalty per destination system outside the radius")
+	fs.Float64Var(&a.InsideTargetPenalty, "inside-target-penalty", a.InsideTargetPenalty, "penalty per target faction in the source systems")
+	fs.BoolVar(&a.WeightGiversByStations, "weight-by-stations", a.WeightGiversByStations, "count a giver faction once per eligible station of its source systems instead of once per system")
+	fs.Float64Var(&a.MissionsPerBoard, "missions-per-board", a.MissionsPerBoard, "massacre missions a giver faction is expected to offer per mission board")
+	fs.Float64Var(&a.KillsPerMission, "kills-per-mission", a.KillsPerMission, "average kills a massacre mission asks for")
+	fs.IntVar(&a.MissionCap, "mission-cap", a.MissionCap, "missions a player can hold at once")
+	fs.BoolVar(&a.WingMissions, "wing", a.WingMissions, "plan with wing missions instead of solo ones")
+	fs.Float64Var(&a.WingRewardFactor, "wing-reward-factor", a.WingRewardFactor, "reward of a wing mission as multiple of a solo mission")
+	fs.StringVar(&a.ReputationTier, "reputation", a.ReputationTier, "reputation with the giver factions, e.g. Allied or Cordial")
+	fs.Var(&a.ReputationRewardFactors, "reputation-factors", "reward factor per reputation tier, e.g. \"Allied=1,Friendly=0.85\"")
+	fs.Float64Var(&a.KillsPerHour, "kills-per-hour", a.KillsPerHour, "assumed kill rate in the RES")
+	fs.Float64Var(&a.MassacreMissionProbability, "mission-probability", a.MassacreMissionProbability, "chance of a massacre mission per giver faction and board refresh")
+	fs.Var(&a.FactionMissionProbabilities, "faction-mission-probabilities", "mission chance of single factions, e.g. \"Faction A=0.5,Faction B=0.1\"")
+	fs.Float64Var(&a.BoardRefreshMinutes, "board-refresh", a.BoardRefreshMinutes, "minutes between mission board refreshes")
+	fs.Float64Var(&a.TravelMinutesPerStation, "travel-minutes", a.TravelMinutesPerStation, "minutes to get from one mission board to the next")
+	fs.Float64Var(&a.MaxSimulatedHours, "max-simulated-hours", a.MaxSimulatedHours, "hours after which a simulated run gives up filling the stack")
+	fs.BoolVar(&a.MultiTargetFactions, "multi-target", a.MultiTargetFactions, "also consider systems with several target factions, each one as its own target")
+	fs.Float64Var(&a.SiblingTargetPenalty, "sibling-target-penalty", a.SiblingTargetPenalty, "penalty per other target faction in the target system, weighted by its influence relative to the target")
+	fs.Var(&a.EconomyBonus, "economy-bonus", "score added by the primary economy of the target system, e.g. \"Extraction=0.5,Tourism=-1\"")
+	fs.Var(&a.GovernmentBonus, "government-bonus", "score added by the government of the target system, e.g. \"Anarchy=1\"")
+	fs.Var(&a.PowerBonus, "power-bonus", "score added by the power controlling the target system, e.g. \"Zachary Hudson=-2\"")
+	fs.Float64Var(&a.PopulationWeight, "population-weight", a.PopulationWeight, "score added per order of magnitude of the target population, negative to prefer small systems")
+}
+
+// Validate reports every nonsensical value in a at once.
+func (a Args) Validate() error {
+	var problems []string
+
+	if a.MissionRadiusLy <= 0 {
+		problems = append(problems, "MissionRadiusLy must be greater than zero")
+	}
+	if a.MissionsPerBoard <= 0 {
+		problems = append(problems, "MissionsPerBoard must be greater than zero")
+	}
+	if a.KillsPerMission <= 0 {
+		problems = append(problems, "KillsPerMission must be greater than zero")
+	}
+	if a.MissionCap < 1 {
+		problems = append(problems, "MissionCap must be at least 1")
+	}
+	problems = append(problems, validateRewardBrackets(a.RewardBrackets)...)
+	if a.WingRewardFactor <= 0 {
+		problems = append(problems, "WingRewardFactor must be greater than zero")
+	}
+	if _, found := a.ReputationRewardFactors.Lookup(a.ReputationTier); !found {
+		problems = append(problems, "ReputationTier \""+a.ReputationTier+"\" has no entry in ReputationRewardFactors")
+	}
+	for tier, factor := range a.ReputationRewardFactors {
+		if factor < 0 {
+			problems = append(problems, "ReputationRewardFactors of \""+tier+"\" must not be negative")
+		}
+	}
+	if a.KillsPerHour <= 0 {
+		problems = append(problems, "KillsPerHour must be greater than zero")
+	}
+	if a.MassacreMissionProbability < 0 || a.MassacreMissionProbability > 1 {
+		problems = append(problems, "MassacreMissionProbability must be between 0 and 1")
+	}
+	for faction, probability := range a.FactionMissionProbabilities {
+		if probability < 0 || probability > 1 {
+			problems = append(problems, "FactionMissionProbabilities of \""+faction+"\" must be between 0 and 1")
+		}
+	}
+	if a.BoardRefreshMinutes <= 0 {
+		problems = append(problems, "BoardRefreshMinutes must be greater than zero")
+	}
+	if a.TravelMinutesPerStation <= 0 {
+		problems = append(problems, "TravelMinutesPerStation must be greater than zero")
+	}
+	if a.MaxSimulatedHours <= 0 {
+		problems = append(problems, "MaxSimulatedHours must be greater than zero")
+	}
+	if len(a.TargetGovernments) == 0 {
+		problems = append(problems, "TargetGovernments must not be empty")
+	}
+	if a.MinSourceSystemCount < 0 {
+		problems = append(problems, "MinSourceSystemCount must not be negative")
+	}
+	if a.MaxOtherDestSystemsForSource < 0 {
+		problems = append(problems, "MaxOtherDestSystemsForSource must not be negative")
+	}
+	if a.MaxOtherDestSystemsForSourceAnarchyCount < 0 {
+		problems = append(problems, "MaxOtherDestSystemsForSourceAnarchyCount must not be negative")
+	}
+	if a.MinSourceStationCount < 0 {
+		problems = append(problems, "MinSourceStationCount must not be negative")
+	}
+	if a.MaxDistanceInLsForStationToBeConsidered <= 0 {
+		problems = append(problems, "MaxDistanceInLsForStationToBeConsidered must be greater than zero")
+	}
+	if a.MinTargetPopulation < 0 {
+		problems = append(problems, "MinTargetPopulation must not be negative")
+	}
+	if a.MaxTargetPopulation < 0 {
+		problems = append(problems, "MaxTargetPopulation must not be negative")
+	}
+	if a.MaxTargetPopulation > 0 && a.MaxTargetPopulation < a.MinTargetPopulation {
+		problems = append(problems, "MaxTargetPopulation must not be below MinTargetPopulation")
+	}
+	for ringType, suitability := range a.RingTypeSuitability {
+		if suitability < 0 {
+			problems = append(problems, "RingTypeSuitability of \""+ringType+"\" must not be negative")
+		}
+	}
+	if a.GasGiantRingFactor < 0 {
+		problems = append(problems, "GasGiantRingFactor must not be negative")
+	}
+	if a.MinRingSuitability < 0 {
+		problems = append(problems, "MinRingSuitability must not be negative")
+	}
+	if a.RingDistanceFalloffLs <= 0 {
+		problems = append(problems, "RingDistanceFalloffLs must be greater than zero")
+	}
+	if !KnownScorers.ContainsFold(a.Scorer) {
+		problems = append(problems, "unknown Scorer \""+a.Scorer+"\", known are "+strings.Join(KnownScorers, ", "))
+	}
+	weights := []struct {
+		name  string
+		value float64
+	}{
+		{"FactionBaseScore", a.FactionBaseScore},
+		{"FactionCountDecay", a.FactionCountDecay},
+		{"RingBonusWeight", a.RingBonusWeight},
+		{"OutsideTargetPenalty", a.OutsideTargetPenalty},
+		{"OutsideSystemPenalty", a.OutsideSystemPenalty},
+		{"InsideTargetPenalty", a.InsideTargetPenalty},
+		{"SiblingTargetPenalty", a.SiblingTargetPenalty},
+	}
+	for _, weight := range weights {
+		if weight.value < 0 || math.IsNaN(weight.value) || math.IsInf(weight.value, 0) {
+			problems = append(problems, weight.name+" must be a finite number that is not negative")
+		}
+	}
+	if math.IsNaN(a.PopulationWeight) || math.IsInf(a.PopulationWeight, 0) {
+		problems = append(problems, "PopulationWeight must be a finite number")
+	}
+	bonuses := []struct {
+		name    string
+		weights WeightMap
+	}{
+		{"EconomyBonus", a.EconomyBonus},
+		{"GovernmentBonus", a.GovernmentBonus},
+		{"PowerBonus", a.PowerBonus},
+	}
+	for _, bonus := range bonuses {
+		for key, value := range bonus.weights {
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				problems = append(problems, bonus.name+" of \""+key+"\" must be a finite number")
+			}
+		}
+	}
+	if a.FactionCountDecay > a.FactionBaseScore {
+		problems = append(problems, "FactionCountDecay must not exceed FactionBaseScore, or factions in a single system would lower the score")
+	}
+	for _, stationType := range a.AllowedStationTypes {
+		if !KnownStationTypes.ContainsFold(stationType) {
+			problems = append(problems, "unknown station type \""+stationType+"\" in AllowedStationTypes, known are "+strings.Join(KnownStationTypes, ", "))
+		}
+	}
+	if len(a.StationTypes()) == 0 {
+		problems = append(problems, "no station type is allowed")
+	}
+	if !a.RequiredServices.ContainsFold(ServiceMissions) {
+		problems = append(problems, "RequiredServices must contain "+ServiceMissions+", stations without a mission board give no missions")
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New("invalid configuration: " + strings.Join(problems, "; "))
 }
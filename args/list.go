@@ -0,0 +1,106 @@
+package args
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// StringList is a flag.Value for comma separated lists. Setting it replaces the whole list,
+// so a flag overrides the list of a config file instead of extending it.
+type StringList []string
+
+func (l *StringList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *StringList) Set(value string) error {
+	list := make(StringList, 0)
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			list = append(list, entry)
+		}
+	}
+	*l = list
+	return nil
+}
+
+// ContainsFold reports whether the list contains value, ignoring case.
+func (l StringList) ContainsFold(value string) bool {
+	for _, entry := range l {
+		if strings.EqualFold(entry, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// WeightMap is a flag.Value for "key=weight" lists like "Metallic=1,Icy=0.5". Like StringList,
+// setting it replaces the whole map.
+type WeightMap map[string]float64
+
+func (m *WeightMap) String() string {
+	if m == nil {
+		return ""
+	}
+	keys := make([]string, 0, len(*m))
+	for key := range *m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	entries := make([]string, 0, len(keys))
+	for _, key := range keys {
+		entries = append(entries, key+"="+strconv.FormatFloat((*m)[key], 'g', -1, 64))
+	}
+	return strings.Join(entries, ",")
+}
+
+func (m *WeightMap) Set(value string) error {
+	weights := make(WeightMap)
+	for _, entry := range strings.Split(value, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		key, weight, found := strings.Cut(entry, "=")
+		if !found {
+			return fmt.Errorf("\"%s\" is not of the form key=weight", entry)
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(weight), 64)
+		if err != nil {
+			return fmt.Errorf("\"%s\": %w", entry, err)
+		}
+		weights[strings.TrimSpace(key)] = parsed
+	}
+	*m = weights
+	return nil
+}
+
+// UnmarshalJSON replaces the whole map like Set does. encoding/json would merge into the default map otherwise,
+// and a config file could not drop a key.
+func (m *WeightMap) UnmarshalJSON(data []byte) error {
+	var weights map[string]float64
+	if err := json.Unmarshal(data, &weights); err != nil {
+		return err
+	}
+	*m = weights
+	return nil
+}
+
+// Lookup returns the weight of key, ignoring case.
+func (m WeightMap) Lookup(key string) (float64, bool) {
+	if weight, ok := m[key]; ok {
+		return weight, true
+	}
+	for candidate, weight := range m {
+		if strings.EqualFold(candidate, key) {
+			return weight, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,96 @@
+package args
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadFile reads a JSON, YAML or TOML config file (picked by extension) on top of base.
+// Keys are the Args field names and are matched case-insensitively, which makes the
+// "config" block of a previous result.json a valid config file.
+func LoadFile(path string, base Args) (Args, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return base, err
+	}
+
+	var generic map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(content, &generic)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &generic)
+	case ".toml":
+		err = toml.Unmarshal(content, &generic)
+	default:
+		return base, fmt.Errorf("%s: unsupported config file type, use .json, .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return base, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// Every format is funneled through encoding/json so that all of them share the same keys.
+	asJson, err := json.Marshal(generic)
+	if err != nil {
+		return base, fmt.Errorf("%s: %w", path, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(asJson))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&base); err != nil {
+		return base, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return base, nil
+}
+
+// Loader wires the Args flags and a -config flag into a FlagSet and resolves the effective
+// configuration after parsing: defaults, then the config file, then explicitly set flags.
+type Loader struct {
+	ConfigPath string
+	fs         *flag.FlagSet
+	flagged    Args
+}
+
+func NewLoader(fs *flag.FlagSet) *Loader {
+	loader := &Loader{fs: fs, flagged: Default()}
+	fs.StringVar(&loader.ConfigPath, "config", "", "path to a JSON, YAML or TOML config file")
+	loader.flagged.RegisterFlags(fs)
+	return loader
+}
+
+// Resolve must be called after the FlagSet has been parsed.
+func (l *Loader) Resolve() (Args, error) {
+	config := Default()
+
+	if l.ConfigPath != "" {
+		var err error
+		config, err = LoadFile(l.ConfigPath, config)
+		if err != nil {
+			return config, err
+		}
+	}
+
+	// Replay the flags the user actually set onto the loaded config.
+	overlay := flag.NewFlagSet("overlay", flag.ContinueOnError)
+	config.RegisterFlags(overlay)
+	var overlayErr error
+	l.fs.Visit(func(f *flag.Flag) {
+		if overlay.Lookup(f.Name) == nil || overlayErr != nil {
+			return
+		}
+		overlayErr = overlay.Set(f.Name, f.Value.String())
+	})
+	if overlayErr != nil {
+		return config, overlayErr
+	}
+
+	return config, config.Validate()
+}
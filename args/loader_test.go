@@ -0,0 +1,66 @@
+package args
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoaderReplacesRewardBrackets(t *testing.T) {
+	configs := map[string]string{
+		"finder.json": `{"RewardBrackets": [{"MaxKills": 30, "MaxReward": 9000000}]}`,
+		"finder.yaml": "RewardBrackets:\n  - MaxKills: 30\n    MaxReward: 9000000\n",
+		"finder.toml": "[[RewardBrackets]]\nMaxKills = 30\nMaxReward = 9000000\n",
+	}
+	want := RewardBracketList{{MaxKills: 30, MaxReward: 9000000}}
+
+	for name, content := range configs {
+		t.Run(name, func(t *testing.T) {
+			config, err := resolveConfig(t, name, content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(config.RewardBrackets, want) {
+				t.Errorf("RewardBrackets %+v, want %+v", config.RewardBrackets, want)
+			}
+			if !reflect.DeepEqual(Default().RewardBrackets, RewardBracketList(DefaultRewardBrackets)) {
+				t.Errorf("loading a config changed the defaults to %+v", Default().RewardBrackets)
+			}
+		})
+	}
+}
+
+func TestLoaderRejectsUnknownRewardBracketFields(t *testing.T) {
+	_, err := resolveConfig(t, "finder.json", `{"RewardBrackets": [{"MaxKills": 30, "MaxRewrd": 9000000}]}`)
+	if err == nil {
+		t.Fatal("a misspelt bracket field was accepted")
+	}
+}
+
+func TestLoaderReplacesWeightMaps(t *testing.T) {
+	config, err := resolveConfig(t, "finder.json", `{"RingTypeSuitability": {"Icy": 1}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (WeightMap{"Icy": 1}); !reflect.DeepEqual(config.RingTypeSuitability, want) {
+		t.Errorf("RingTypeSuitability %v, want %v", config.RingTypeSuitability, want)
+	}
+}
+
+// resolveConfig writes content to a config file called name and resolves it like a command does.
+func resolveConfig(t *testing.T, name string, content string) (Args, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	loader := NewLoader(fs)
+	if err := fs.Parse([]string{"-config", path}); err != nil {
+		t.Fatal(err)
+	}
+	return loader.Resolve()
+}
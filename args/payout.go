@@ -0,0 +1,78 @@
+package args
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// RewardBracket is the reward range of a solo massacre mission asking for up to MaxKills kills, at Allied reputation.
+type RewardBracket struct {
+	MaxKills  int
+	MinReward float64
+	MaxReward float64
+}
+
+// RewardBracketList is the RewardBrackets setting.
+type RewardBracketList []RewardBracket
+
+// UnmarshalJSON replaces the whole list. encoding/json would decode into the elements of the default list otherwise,
+// and a bracket would keep the default value of every field the config file leaves out.
+func (l *RewardBracketList) UnmarshalJSON(data []byte) error {
+	var brackets []RewardBracket
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&brackets); err != nil {
+		return err
+	}
+	*l = brackets
+	return nil
+}
+
+// DefaultRewardBrackets are rough solo rewards as seen on mission boards, in credits.
+var DefaultRewardBrackets = []RewardBracket{
+	{MaxKills: 12, MinReward: 1500000, MaxReward: 4000000},
+	{MaxKills: 24, MinReward: 3000000, MaxReward: 6000000},
+	{MaxKills: 40, MinReward: 5000000, MaxReward: 8000000},
+	{MaxKills: 60, MinReward: 6000000, MaxReward: 10000000},
+}
+
+// Reputation tiers with the giver factions, from best to worst.
+const (
+	ReputationAllied     = "Allied"
+	ReputationFriendly   = "Friendly"
+	ReputationCordial    = "Cordial"
+	ReputationNeutral    = "Neutral"
+	ReputationUnfriendly = "Unfriendly"
+	ReputationHostile    = "Hostile"
+)
+
+// RewardBracketFor returns the bracket a mission with kills kills falls into, the last one beyond all of them.
+func (a Args) RewardBracketFor(kills float64) RewardBracket {
+	for _, bracket := range a.RewardBrackets {
+		if kills <= float64(bracket.MaxKills) {
+			return bracket
+		}
+	}
+	return a.RewardBrackets[len(a.RewardBrackets)-1]
+}
+
+func validateRewardBrackets(brackets []RewardBracket) []string {
+	if len(brackets) == 0 {
+		return []string{"RewardBrackets must not be empty"}
+	}
+	var problems []string
+	if !sort.SliceIsSorted(brackets, func(i, j int) bool { return brackets[i].MaxKills < brackets[j].MaxKills }) {
+		problems = append(problems, "RewardBrackets must be sorted by MaxKills")
+	}
+	for i, bracket := range brackets {
+		if bracket.MaxKills < 1 {
+			problems = append(problems, fmt.Sprintf("RewardBrackets[%d].MaxKills must be at least 1", i))
+		}
+		if bracket.MinReward < 0 || bracket.MaxReward < bracket.MinReward {
+			problems = append(problems, fmt.Sprintf("RewardBrackets[%d] must have 0 <= MinReward <= MaxReward", i))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,11 @@
+package args
+
+// Names of the scoring formulas, implemented in the evaluation package.
+const (
+	ScorerDefault  = "default"
+	ScorerFactions = "factions"
+	ScorerRatio    = "ratio"
+)
+
+// KnownScorers lists every value Scorer may have.
+var KnownScorers = StringList{ScorerDefault, ScorerFactions, ScorerRatio}
@@ -0,0 +1,60 @@
+package args
+
+import "strings"
+
+// Station types as named in the spansh dumps.
+const (
+	StationOutpost          = "Outpost"
+	StationCoriolis         = "Coriolis Starport"
+	StationOrbis            = "Orbis Starport"
+	StationOcellus          = "Ocellus Starport"
+	StationAsteroidBase     = "Asteroid base"
+	StationPlanetaryOutpost = "Planetary Outpost"
+	StationPlanetaryPort    = "Planetary Port"
+	StationSettlement       = "Settlement"
+	StationMegaship         = "Mega ship"
+	StationFleetCarrier     = "Drake-Class Carrier"
+)
+
+// ServiceMissions is the station service of the mission board, every mission source needs it.
+const ServiceMissions = "Missions"
+
+// KnownStationTypes lists every station type AllowedStationTypes may contain.
+var KnownStationTypes = StringList{
+	StationOutpost, StationCoriolis, StationOrbis, StationOcellus, StationAsteroidBase,
+	StationPlanetaryOutpost, StationPlanetaryPort, StationSettlement, StationMegaship, StationFleetCarrier,
+}
+
+// StationTypes returns the station types a mission-source station may have: AllowedStationTypes plus the types
+// switched on by ConsiderGroundBases and ConsiderOdysseySettlements. Fleet carriers and megaships are only
+// included if IncludeFleetCarriers / IncludeMegaships is set, whether they are listed or not.
+func (a Args) StationTypes() []string {
+	types := make(StringList, 0, len(a.AllowedStationTypes)+4)
+	add := func(stationType string) {
+		if !types.ContainsFold(stationType) {
+			types = append(types, stationType)
+		}
+	}
+
+	for _, stationType := range a.AllowedStationTypes {
+		if (strings.EqualFold(stationType, StationFleetCarrier) && !a.IncludeFleetCarriers) || (strings.EqualFold(stationType, StationMegaship) && !a.IncludeMegaships) {
+			continue
+		}
+		add(stationType)
+	}
+	if a.ConsiderGroundBases {
+		add(StationPlanetaryOutpost)
+		add(StationPlanetaryPort)
+	}
+	if a.ConsiderOdysseySettlements {
+		add(StationSettlement)
+	}
+	if a.IncludeFleetCarriers {
+		add(StationFleetCarrier)
+	}
+	if a.IncludeMegaships {
+		add(StationMegaship)
+	}
+
+	return types
+}
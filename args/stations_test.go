@@ -0,0 +1,37 @@
+package args
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStationTypesExcludeCarriersAndMegashipsInAnyCase(t *testing.T) {
+	config := Default()
+	config.AllowedStationTypes = StringList{StationOutpost, "drake-class carrier", "MEGA SHIP"}
+
+	types := StringList(config.StationTypes())
+	if types.ContainsFold(StationFleetCarrier) || types.ContainsFold(StationMegaship) {
+		t.Errorf("StationTypes() = %v without IncludeFleetCarriers and IncludeMegaships", types)
+	}
+
+	config.IncludeFleetCarriers = true
+	if types := StringList(config.StationTypes()); !types.ContainsFold(StationFleetCarrier) || len(types) != 2 {
+		t.Errorf("StationTypes() = %v with IncludeFleetCarriers", types)
+	}
+}
+
+func TestValidateRequiresMissions(t *testing.T) {
+	for _, services := range []StringList{{}, {"Restock"}} {
+		config := Default()
+		config.RequiredServices = services
+		if err := config.Validate(); err == nil || !strings.Contains(err.Error(), "RequiredServices") {
+			t.Errorf("RequiredServices %v: got %v, want a RequiredServices problem", services, err)
+		}
+	}
+
+	config := Default()
+	config.RequiredServices = StringList{"missions", "Restock"}
+	if err := config.Validate(); err != nil {
+		t.Errorf("RequiredServices %v: %v", config.RequiredServices, err)
+	}
+}
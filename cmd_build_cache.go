@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"massacre-finder/dataBuilder"
+	"os"
+	"strconv"
+	"time"
+)
+
+func runBuildCache(arguments []string) int {
+	fs := newFlagSet("build-cache")
+	var data dataFlags
+	data.register(fs)
+	if code, ok := parseFlags(fs, arguments); !ok {
+		return code
+	}
+
+	systemList, err := data.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+
+	header, err := dataBuilder.ReadCacheHeader(data.cachePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+
+	fmt.Println("Cache " + data.cachePath + " holds " + strconv.Itoa(len(systemList)) + " Systems.")
+	fmt.Printf("  schema version %d, built %s by version %s\n", header.SchemaVersion, header.CreatedAt.Format(time.RFC3339), header.ToolVersion)
+	fmt.Printf("  source %s (%d bytes, sha256 %s)\n", header.Source.Path, header.Source.Size, header.Source.SHA256)
+	for _, sideFile := range header.Source.SideFiles {
+		fmt.Printf("  side file %s (%d bytes, sha256 %s)\n", sideFile.Path, sideFile.Size, sideFile.SHA256)
+	}
+	if len(header.Updates) > 0 {
+		fmt.Printf("  %d delta dumps applied, latest update %s\n", len(header.Updates), header.LastUpdate.Format(time.RFC3339))
+	}
+	return exitOK
+}
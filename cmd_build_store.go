@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+	"massacre-finder/dataBuilder"
+	"os"
+	"strconv"
+)
+
+func runBuildStore(arguments []string) int {
+	fs := newFlagSet("build-store")
+	var data dataFlags
+	data.register(fs)
+	storePath := fs.String("store", "./systems.db", "path of the SQLite store to write, an existing one is replaced")
+	if code, ok := parseFlags(fs, arguments); !ok {
+		return code
+	}
+
+	systemList, err := data.load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	header, err := dataBuilder.ReadCacheHeader(data.cachePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+
+	if err := dataBuilder.BuildSQLiteStore(*storePath, header, systemList); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+
+	fmt.Println("Store " + *storePath + " holds " + strconv.Itoa(len(systemList)) + " Systems.")
+	return exitOK
+}
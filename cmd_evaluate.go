@@ -0,0 +1,193 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"massacre-finder/args"
+	"massacre-finder/dataBuilder"
+	"massacre-finder/evaluation"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+func runEvaluate(arguments []string) int {
+	fs := newFlagSet("evaluate")
+	var data dataFlags
+	data.register(fs)
+	data.registerStore(fs)
+	configLoader := args.NewLoader(fs)
+	outPath := fs.String("out", "./result.json", "path of the result file")
+	top := fs.Int("top", 10, "number of results to print to the console")
+	workers := fs.Int("workers", 10, "number of systems evaluated in parallel")
+	sortBy := fs.String("sort", "score", "order of the results: score, or income for the estimated credits per hour")
+	if code, ok := parseFlags(fs, arguments); !ok {
+		return code
+	}
+
+	config, err := configLoader.Resolve()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if *workers < 1 {
+		fmt.Fprintln(os.Stderr, "-workers must be at least 1")
+		return exitUsage
+	}
+	if *top < 0 {
+		fmt.Fprintln(os.Stderr, "-top must not be negative")
+		return exitUsage
+	}
+	if *sortBy != "score" && *sortBy != "income" {
+		fmt.Fprintln(os.Stderr, "-sort must be score or income")
+		return exitUsage
+	}
+
+	store, closeStore, err := data.openStore(config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	defer closeStore()
+
+	results, statistics, err := evaluateAll(store, config, *workers)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	if *sortBy == "income" {
+		sort.SliceStable(results, func(i, j int) bool {
+			return results[i].Payout.CreditsPerHour > results[j].Payout.CreditsPerHour
+		})
+	}
+	fmt.Println("Found " + strconv.Itoa(len(results)) + " Results.")
+
+	countToDisplay := len(results)
+	if countToDisplay > *top {
+		countToDisplay = *top
+	}
+
+	for i, entry := range results[:countToDisplay] {
+		name := entry.SystemName
+		if len(entry.SiblingTargetFactions) > 0 {
+			name += " (" + entry.TargetFactionName + ")"
+		}
+		fmt.Println("[", i+1, "]: ", name, " @ ", entry.Score)
+		fmt.Println("       ", entry.ScoreBreakdown)
+		fmt.Printf("        stack of %.1f missions, %.0f kills, ratio %.1f, %.1fM credits in %.1fh = %.1fM/h\n",
+			entry.Stack.TotalMissions, entry.Stack.RequiredKills, entry.Stack.StackingRatio,
+			entry.Payout.CreditsPerStack/1e6, entry.Payout.HoursPerStack, entry.Payout.CreditsPerHour/1e6)
+	}
+
+	printStatistics(statistics)
+
+	if _, err := buildAndWriteResult(*outPath, config, results, statistics); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// evaluateAll evaluates every system in parallel and returns the relevant ones sorted by score,
+// along with the statistics of why the others were rejected. Systems are handed to the workers through a
+// bounded channel, so a store that reads them lazily is only ever a few systems ahead of the evaluation.
+func evaluateAll(store dataBuilder.SystemStore, config args.Args, workers int) ([]evaluation.SystemEvaluationResult, evaluation.RejectionStatistics, error) {
+	var mutex sync.Mutex
+	var results = make([]evaluation.SystemEvaluationResult, 0, 100)
+	statistics := evaluation.NewRejectionStatistics()
+
+	systems := make(chan dataBuilder.EliteSystem, workers)
+	failed := make(chan struct{})
+	var failure error
+	var failOnce sync.Once
+
+	var wait sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			for system := range systems {
+				responses, rejection, err := evaluation.EvaluateSystem(system, store, config)
+				if err != nil {
+					failOnce.Do(func() {
+						failure = err
+						close(failed)
+					})
+					return
+				}
+
+				mutex.Lock()
+				statistics.Add(system, len(responses), rejection)
+				results = append(results, responses...)
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	errStopped := errors.New("evaluation stopped")
+	err := store.ForEachSystem(func(system dataBuilder.EliteSystem) error {
+		select {
+		case systems <- system:
+			return nil
+		case <-failed:
+			return errStopped
+		}
+	})
+	close(systems)
+
+	// Wait for all Systems to be evaluated
+	wait.Wait()
+	if failure != nil {
+		err = failure
+	}
+	if err != nil {
+		return nil, statistics, err
+	}
+
+	// Sort results
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	return results, statistics, nil
+}
+
+func printStatistics(statistics evaluation.RejectionStatistics) {
+	fmt.Printf("Evaluated %d systems, %d targets.\n", statistics.Evaluated, statistics.Targets)
+	for _, reason := range statistics.ReasonsByCount() {
+		fmt.Printf("  %7d rejected: %s\n", statistics.Rejected[reason], evaluation.Rejection{Reason: reason}.Rule())
+	}
+	stations := statistics.RejectedStations
+	fmt.Printf("  Stations dropped: %d missing services, %d too far, %d wrong type, %d without large pad\n",
+		stations.Services, stations.Distance, stations.Type, stations.LandingPad)
+}
+
+// ResultSchemaVersion is increased whenever the layout of result.json changes. Version 2 renamed the anarchy
+// counters to target (TargetGovernments) and giver factions.
+const ResultSchemaVersion = 2
+
+type Result struct {
+	SchemaVersion int                                 `json:"schemaVersion"`
+	Config        args.Args                           `json:"config"`
+	Statistics    evaluation.RejectionStatistics      `json:"statistics"`
+	SortedResult  []evaluation.SystemEvaluationResult `json:"sortedResult,omitempty"`
+}
+
+func buildAndWriteResult(path string, args args.Args, result []evaluation.SystemEvaluationResult, statistics evaluation.RejectionStatistics) (Result, error) {
+
+	returnVal := Result{
+		SchemaVersion: ResultSchemaVersion,
+		Config:        args,
+		Statistics:    statistics,
+		SortedResult:  result,
+	}
+
+	jsonString, err := json.MarshalIndent(returnVal, "", "\t")
+	if err != nil {
+		return returnVal, err
+	}
+
+	return returnVal, ioutil.WriteFile(path, jsonString, os.ModePerm)
+}
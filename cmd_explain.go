@@ -0,0 +1,21 @@
+package main
+
+import (
+	"fmt"
+	"massacre-finder/args"
+	"massacre-finder/dataBuilder"
+	"massacre-finder/evaluation"
+)
+
+// runExplain only shows the evaluation of a system, to find out why a known stack site is missing from the results.
+func runExplain(arguments []string) int {
+	return runSystemCommand("explain", arguments, nil, nil, func(system dataBuilder.EliteSystem, store dataBuilder.SystemStore, config args.Args) error {
+		results, rejection, err := evaluation.EvaluateSystem(system, store, config)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s: ", system.Name)
+		printEvaluation(results, rejection, config)
+		return nil
+	})
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"massacre-finder/args"
+	"massacre-finder/dataBuilder"
+	"massacre-finder/evaluation"
+	"os"
+	"strings"
+)
+
+func runInspect(arguments []string) int {
+	return runSystemCommand("inspect", arguments, nil, nil, func(system dataBuilder.EliteSystem, store dataBuilder.SystemStore, config args.Args) error {
+		printSystem(system, config)
+		fmt.Println()
+
+		results, rejection, err := evaluation.EvaluateSystem(system, store, config)
+		if err != nil {
+			return err
+		}
+		printEvaluation(results, rejection, config)
+		return nil
+	})
+}
+
+// runSystemCommand parses the flags of a command that works on the system named by the positional arguments,
+// looks the system up and hands it to show. register may add flags of the command itself and validate check them
+// before any data is loaded, both may be nil.
+func runSystemCommand(name string, arguments []string, register func(fs *flag.FlagSet), validate func() error, show func(system dataBuilder.EliteSystem, store dataBuilder.SystemStore, config args.Args) error) int {
+	fs := newFlagSet(name)
+	var data dataFlags
+	data.register(fs)
+	data.registerStore(fs)
+	configLoader := args.NewLoader(fs)
+	if register != nil {
+		register(fs)
+	}
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: massacre-finder "+name+" [flags] <system name>")
+		fs.PrintDefaults()
+	}
+	if code, ok := parseFlags(fs, arguments); !ok {
+		return code
+	}
+
+	systemName := strings.Join(fs.Args(), " ")
+	if systemName == "" {
+		fs.Usage()
+		return exitUsage
+	}
+	if validate != nil {
+		if err := validate(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+	}
+
+	config, err := configLoader.Resolve()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	store, closeStore, err := data.openStore(config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	defer closeStore()
+
+	system, found, err := store.FindSystem(systemName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	if !found {
+		fmt.Fprintln(os.Stderr, "no populated system named \""+systemName+"\"")
+		return exitNotFound
+	}
+
+	if err := show(system, store, config); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	return exitOK
+}
+
+// printEvaluation shows the score of every target in the system, or the rule that ruled the system out.
+func printEvaluation(results []evaluation.SystemEvaluationResult, rejection *evaluation.Rejection, config args.Args) {
+	if rejection != nil {
+		fmt.Println("Not a massacre target with the current configuration.")
+		fmt.Printf("  Rule:      %s\n", rejection.Rule())
+		fmt.Printf("  Observed:  %s\n", rejection.Observed)
+		fmt.Printf("  Threshold: %s\n", rejection.Threshold)
+		return
+	}
+	for _, result := range results {
+		printResult(result, config)
+	}
+}
+
+func printResult(result evaluation.SystemEvaluationResult, config args.Args) {
+	fmt.Printf("Score %.3f targeting %s\n", result.Score, result.TargetFactionName)
+	if len(result.SiblingTargetFactions) > 0 {
+		fmt.Printf("  sibling target factions: %s\n", strings.Join(result.SiblingTargetFactions, ", "))
+	}
+	fmt.Printf("  %s\n", result.ScoreBreakdown)
+	// A faction counts once per source system, or once per station with WeightGiversByStations.
+	unit := "systems"
+	if config.WeightGiversByStations {
+		unit = "stations"
+	}
+	for _, faction := range result.ScoreBreakdown.Factions {
+		fmt.Printf("    %-40s %2d %-8s %+6.2f\n", faction.Name, faction.Systems, unit, faction.Score)
+	}
+	fmt.Printf("  expected stack of %.1f missions needing %.0f kills, stacking ratio %.1f\n",
+		result.Stack.TotalMissions, result.Stack.RequiredKills, result.Stack.StackingRatio)
+	if result.Stack.TargetShare < 1 {
+		fmt.Printf("  %.0f%% of the missions of every giver are against %s, the sibling target factions get the rest\n",
+			result.Stack.TargetShare*100, result.TargetFactionName)
+	}
+	fmt.Printf("  payout %.1fM per mission, %.1fM per stack in %.1fh, %.1fM credits per hour\n", result.Payout.RewardPerMission/1e6,
+		result.Payout.CreditsPerStack/1e6, result.Payout.HoursPerStack, result.Payout.CreditsPerHour/1e6)
+	fmt.Printf("  %d source systems, %d sourcing factions, %d target factions in the source systems\n",
+		result.SourcingSystems, result.GiverFactionsCount, result.SourceSystemTargetFactionCount)
+	fmt.Printf("  %d other destination systems with %d target factions\n",
+		result.ExternalSystemCount, result.ExternalSystemTargetFactionCount)
+	if result.NearestSuitableRing != nil {
+		fmt.Printf("  nearest RES ring %s (%s, %.0f Ls, suitability %.2f)\n", result.NearestSuitableRing.Name,
+			result.NearestSuitableRing.Type, result.NearestSuitableRing.DistanceToArrival, result.RingSuitability)
+	}
+}
+
+func printSystem(system dataBuilder.EliteSystem, config args.Args) {
+	fmt.Printf("%s (id64 %d) at %.2f / %.2f / %.2f\n", system.Name, system.Id, system.X, system.Y, system.Z)
+	fmt.Printf("  Security level %d, %d ringed bodies\n", system.SystemSecurityLevel, system.RingQty)
+	fmt.Printf("  Population %d, %s / %s economy, %s %s, controlled by %s\n", system.Population, system.PrimaryEconomy,
+		system.SecondaryEconomy, system.Allegiance, system.Government, system.ControllingFaction)
+	if system.ControllingPower != "" || len(system.Powers) > 0 {
+		fmt.Printf("  Powerplay: controlled by %q, state %q, powers present: %s\n", system.ControllingPower, system.PowerState, strings.Join(system.Powers, ", "))
+	}
+	fmt.Printf("  Target factions (%d): %s\n", system.TargetFactionCount, strings.Join(system.TargetFactionNames, ", "))
+	fmt.Printf("  Giver factions (%d): %s\n", system.GiverFactionCount, strings.Join(system.GiverFactionNames, ", "))
+	fmt.Println("  Factions:")
+	for _, faction := range system.Factions {
+		fmt.Printf("    %-40s %-14s %-12s %5.1f%%  %s\n", faction.Name, faction.Government, faction.Allegiance,
+			faction.Influence*100, strings.Join(faction.ActiveStates, ", "))
+	}
+	fmt.Printf("  Rings (%d):\n", len(system.Rings))
+	for _, ring := range system.Rings {
+		kind := ring.BodySubType
+		if ring.IsBelt {
+			kind = "belt"
+		}
+		fmt.Printf("    %-30s %-11s %8.0f Ls  suitability %.2f  %s\n",
+			ring.Name, ring.Type, ring.DistanceToArrival, evaluation.RingSuitability(ring, config), kind)
+	}
+	fmt.Printf("  Eligible stations (%d):\n", len(system.Stations))
+	for _, station := range system.Stations {
+		location := "orbital"
+		if station.IsPlanetary {
+			location = "on " + station.BodyName
+		}
+		fmt.Printf("    %-30s %-20s %8.0f Ls  pad %-1s  %-12s %s\n",
+			station.Name, station.Type, station.Distance, station.MaxLandingPad, station.PrimaryEconomy, location)
+	}
+}
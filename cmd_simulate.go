@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"massacre-finder/args"
+	"massacre-finder/dataBuilder"
+	"massacre-finder/evaluation"
+	"massacre-finder/simulation"
+	"math/rand"
+	"time"
+)
+
+// runSimulate plays mission board refreshes around the targets of a system to see how long a full stack takes.
+func runSimulate(arguments []string) int {
+	var runs int
+	var seed int64
+	register := func(fs *flag.FlagSet) {
+		fs.IntVar(&runs, "runs", 1000, "number of simulated runs")
+		fs.Int64Var(&seed, "seed", 0, "seed of the simulation, 0 for a random one")
+	}
+	validate := func() error {
+		if runs < 1 {
+			return fmt.Errorf("-runs must be at least 1")
+		}
+		return nil
+	}
+
+	return runSystemCommand("simulate", arguments, register, validate, func(system dataBuilder.EliteSystem, store dataBuilder.SystemStore, config args.Args) error {
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+
+		results, rejection, err := evaluation.EvaluateSystem(system, store, config)
+		if err != nil {
+			return err
+		}
+		if rejection != nil {
+			fmt.Printf("%s: ", system.Name)
+			printEvaluation(results, rejection, config)
+			return nil
+		}
+
+		for _, result := range results {
+			boards, err := simulation.Boards(result, store, config)
+			if err != nil {
+				return err
+			}
+			systems := make(map[string]bool)
+			for _, board := range boards {
+				systems[board.System] = true
+			}
+
+			distribution := simulation.Simulate(boards, config, runs, rand.New(rand.NewSource(seed)))
+			fmt.Printf("%s targeting %s: %d mission boards in %d systems, seed %d\n",
+				result.SystemName, result.TargetFactionName, len(boards), len(systems), seed)
+			fmt.Printf("  %d of %d runs filled %d missions within %.0fh\n",
+				distribution.Filled, distribution.Runs, config.MissionCap, config.MaxSimulatedHours)
+			if distribution.Filled > 0 {
+				fmt.Printf("  minutes to fill: mean %.0f, min %.0f, p10 %.0f, median %.0f, p90 %.0f, max %.0f\n",
+					distribution.Mean, distribution.Min, distribution.P10, distribution.Median, distribution.P90, distribution.Max)
+			}
+		}
+		return nil
+	})
+}
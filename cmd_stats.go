@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"massacre-finder/args"
+	"massacre-finder/dataBuilder"
+	"os"
+	"sort"
+)
+
+func runStats(arguments []string) int {
+	fs := newFlagSet("stats")
+	var data dataFlags
+	data.register(fs)
+	data.registerStore(fs)
+	configLoader := args.NewLoader(fs)
+	if code, ok := parseFlags(fs, arguments); !ok {
+		return code
+	}
+
+	config, err := configLoader.Resolve()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	store, closeStore, err := data.openStore(config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	defer closeStore()
+
+	systemCount := 0
+	ringedCount := 0
+	stationCount := 0
+	systemsWithStations := 0
+	targetDistribution := make(map[int]int)
+	factions := make(map[string]bool)
+
+	err = store.ForEachSystem(func(system dataBuilder.EliteSystem) error {
+		systemCount++
+		if system.RingQty > 0 {
+			ringedCount++
+		}
+		stationCount += len(system.Stations)
+		if len(system.Stations) > 0 {
+			systemsWithStations++
+		}
+		targetDistribution[int(system.TargetFactionCount)]++
+		for _, name := range system.TargetFactionNames {
+			factions[name] = true
+		}
+		for _, name := range system.GiverFactionNames {
+			factions[name] = true
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+
+	fmt.Printf("Systems:                     %d\n", systemCount)
+	fmt.Printf("Distinct factions:           %d\n", len(factions))
+	fmt.Printf("Systems with rings:          %d\n", ringedCount)
+	fmt.Printf("Eligible stations:           %d\n", stationCount)
+	fmt.Printf("Systems with eligible st.:   %d\n", systemsWithStations)
+	fmt.Println("Systems by target faction count:")
+	counts := make([]int, 0, len(targetDistribution))
+	for count := range targetDistribution {
+		counts = append(counts, count)
+	}
+	sort.Ints(counts)
+	for _, count := range counts {
+		fmt.Printf("  %d: %d\n", count, targetDistribution[count])
+	}
+	return exitOK
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"massacre-finder/dataBuilder"
+	"os"
+	"time"
+)
+
+func runUpdateCache(arguments []string) int {
+	fs := newFlagSet("update-cache")
+	cachePath := fs.String("cache", "./system_cache.bin", "path of the system cache to update")
+	format := fs.String("format", dataBuilder.FormatSpansh, "format of the delta dumps: auto, spansh or edsm")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: massacre-finder update-cache [flags] <delta dump>...")
+		fmt.Fprintln(fs.Output(), "Applies spansh delta dumps (galaxy_1day.json.gz, galaxy_7days.json.gz, ...) in the given order.")
+		fs.PrintDefaults()
+	}
+	if code, ok := parseFlags(fs, arguments); !ok {
+		return code
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return exitUsage
+	}
+
+	deltas := make([]dataBuilder.Source, 0, fs.NArg())
+	for _, path := range fs.Args() {
+		deltas = append(deltas, dataBuilder.Source{Path: path, Format: *format})
+	}
+
+	results, err := dataBuilder.UpdateCache(*cachePath, deltas)
+	for _, result := range results {
+		fmt.Printf("%s: read %d, added %d, replaced %d, removed %d, unchanged %d, malformed %d\n",
+			result.Path, result.Read, result.Added, result.Replaced, result.Removed, result.Unchanged, result.Skipped)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+
+	header, err := dataBuilder.ReadCacheHeader(*cachePath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitFailure
+	}
+	fmt.Printf("Cache %s holds %d Systems, latest update %s.\n", *cachePath, header.RecordCount, header.LastUpdate.Format(time.RFC3339))
+	return exitOK
+}
@@ -0,0 +1,266 @@
+package dataBuilder
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CacheSchemaVersion must be increased whenever EliteSystemJSON changes in a way that makes old caches unusable.
+const CacheSchemaVersion = 6
+
+// ToolVersion is recorded in the cache header. Release builds set it with
+// -ldflags "-X massacre-finder/dataBuilder.ToolVersion=...".
+var ToolVersion = "dev"
+
+// ErrCacheCorrupt is returned (wrapped) when a cache file can not be read back.
+var ErrCacheCorrupt = errors.New("system cache is corrupt")
+
+var cacheMagic = []byte("MMFCACHE")
+
+// SourceFingerprint identifies the galaxy dump a cache was built from.
+type SourceFingerprint struct {
+	Path      string
+	Format    string
+	Size      int64
+	ModTime   time.Time
+	SHA256    string
+	SideFiles []FileFingerprint // the EDSM stations and bodies dumps merged into the source, if any
+}
+
+// FileFingerprint identifies a side file of the source.
+type FileFingerprint struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+	SHA256  string
+}
+
+// sideFiles returns the paths of the side files merged into source, stations first.
+func (s Source) sideFiles() []string {
+	paths := make([]string, 0, 2)
+	for _, path := range []string{s.EDSMStationsPath, s.EDSMBodiesPath} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+func fingerprintFile(path string) (FileFingerprint, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return FileFingerprint{}, err
+	}
+	sum, err := hashFile(path)
+	if err != nil {
+		return FileFingerprint{}, err
+	}
+	return FileFingerprint{Path: path, Size: stat.Size(), ModTime: stat.ModTime(), SHA256: sum}, nil
+}
+
+// CacheHeader precedes the records in a cache file.
+type CacheHeader struct {
+	SchemaVersion int
+	ToolVersion   string
+	CreatedAt     time.Time
+	Source        SourceFingerprint
+	RecordCount   int
+	// LastUpdate is the newest record date applied from a delta dump, zero if there never was an update.
+	LastUpdate time.Time
+	Updates    []AppliedUpdate
+}
+
+// The cache file is the magic, followed by a gob stream of the CacheHeader and RecordCount EliteSystemJSON values.
+// Records are encoded one by one so that neither writing nor reading needs a second copy of the whole list.
+
+func writeCache(path string, header CacheHeader, systems []EliteSystemJSON) error {
+	// Write next to the target and rename, so an interrupted build never leaves a truncated cache behind.
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	writer := bufio.NewWriter(file)
+	if _, err := writer.Write(cacheMagic); err != nil {
+		file.Close()
+		return err
+	}
+
+	header.RecordCount = len(systems)
+	encoder := gob.NewEncoder(writer)
+	if err := encoder.Encode(header); err != nil {
+		file.Close()
+		return err
+	}
+	for i := range systems {
+		if err := encoder.Encode(&systems[i]); err != nil {
+			file.Close()
+			return err
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(file.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// openCache reads the header of the cache at path and returns a decoder positioned at the first record.
+func openCache(path string) (*os.File, *gob.Decoder, CacheHeader, error) {
+	var header CacheHeader
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, header, err
+	}
+
+	reader := bufio.NewReader(file)
+	magic := make([]byte, len(cacheMagic))
+	if _, err := io.ReadFull(reader, magic); err != nil || !bytes.Equal(magic, cacheMagic) {
+		file.Close()
+		return nil, nil, header, fmt.Errorf("%s: %w: not a system cache file", path, ErrCacheCorrupt)
+	}
+
+	decoder := gob.NewDecoder(reader)
+	if err := decoder.Decode(&header); err != nil {
+		file.Close()
+		return nil, nil, header, fmt.Errorf("%s: %w: unreadable header: %v", path, ErrCacheCorrupt, err)
+	}
+
+	return file, decoder, header, nil
+}
+
+// ReadCacheHeader returns the header of the cache at path without reading the records.
+func ReadCacheHeader(path string) (CacheHeader, error) {
+	file, _, header, err := openCache(path)
+	if err != nil {
+		return header, err
+	}
+	file.Close()
+	return header, nil
+}
+
+// readCache returns the header and all records of the cache at path.
+func readCache(path string) (CacheHeader, []EliteSystemJSON, error) {
+	file, decoder, header, err := openCache(path)
+	if err != nil {
+		return header, nil, err
+	}
+	defer file.Close()
+
+	if header.SchemaVersion != CacheSchemaVersion {
+		return header, nil, fmt.Errorf("%s: cache schema version %d is not supported, expected %d", path, header.SchemaVersion, CacheSchemaVersion)
+	}
+
+	// Every record takes at least a byte, a count beyond the file size can only come from a damaged header.
+	stat, err := file.Stat()
+	if err != nil {
+		return header, nil, err
+	}
+	if header.RecordCount < 0 || int64(header.RecordCount) > stat.Size() {
+		return header, nil, fmt.Errorf("%s: %w: header claims %d records", path, ErrCacheCorrupt, header.RecordCount)
+	}
+
+	systems := make([]EliteSystemJSON, header.RecordCount)
+	for i := range systems {
+		if err := decoder.Decode(&systems[i]); err != nil {
+			return header, nil, fmt.Errorf("%s: %w: record %d of %d: %v", path, ErrCacheCorrupt, i+1, header.RecordCount, err)
+		}
+	}
+
+	return header, systems, nil
+}
+
+// staleReason explains why a cache with the given header has to be rebuilt from source, or returns "" if it is current.
+func staleReason(header CacheHeader, source Source) (string, error) {
+	if header.SchemaVersion != CacheSchemaVersion {
+		return fmt.Sprintf("cache schema version %d, expected %d", header.SchemaVersion, CacheSchemaVersion), nil
+	}
+	// There is nothing to compare a stream against, and a dump is only piped in to be used.
+	if source.Path == StdinPath {
+		return "the source is read from stdin", nil
+	}
+	if !strings.EqualFold(source.Format, header.Source.Format) {
+		return fmt.Sprintf("format %s, the cache was built as %s", source.Format, header.Source.Format), nil
+	}
+	changed, err := fileChanged(source.Path, header.Source.Size, header.Source.ModTime, header.Source.SHA256)
+	if err != nil {
+		return "", err
+	}
+	if changed {
+		return "source " + source.Path + " has changed", nil
+	}
+
+	sideFiles := source.sideFiles()
+	if len(sideFiles) != len(header.Source.SideFiles) {
+		return "the side files of the source have changed", nil
+	}
+	for i, path := range sideFiles {
+		recorded := header.Source.SideFiles[i]
+		if path != recorded.Path {
+			return "side file " + path + " was not used for the cache", nil
+		}
+		changed, err := fileChanged(path, recorded.Size, recorded.ModTime, recorded.SHA256)
+		if err != nil {
+			return "", err
+		}
+		if changed {
+			return "side file " + path + " has changed", nil
+		}
+	}
+	return "", nil
+}
+
+// fileChanged reports whether the file at path differs from the recorded fingerprint.
+func fileChanged(path string, size int64, modTime time.Time, sha string) (bool, error) {
+	stat, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		// Keep using the cache if the dump has been deleted to save space.
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if stat.Size() == size && stat.ModTime().Equal(modTime) {
+		return false, nil
+	}
+
+	// Size or time differ, only a different content makes the cache stale though (e.g. after a copy).
+	sum, err := hashFile(path)
+	if err != nil {
+		return false, err
+	}
+	return sum != sha, nil
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	digest := sha256.New()
+	if _, err := io.Copy(digest, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
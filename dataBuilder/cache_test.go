@@ -0,0 +1,63 @@
+package dataBuilder
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestStaleReason(t *testing.T) {
+	dir := t.TempDir()
+	source := Source{Path: writeTestFile(t, dir, "galaxy_populated.json", updateTestDump), Format: FormatAuto}
+	cachePath := filepath.Join(dir, "system_cache.bin")
+	if _, err := buildCacheFile(cachePath, source); err != nil {
+		t.Fatal(err)
+	}
+	header, err := ReadCacheHeader(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		source Source
+		stale  bool
+	}{
+		{"unchanged", source, false},
+		{"stdin", Source{Path: StdinPath, Format: FormatAuto}, true},
+		{"other format", Source{Path: source.Path, Format: FormatEDSM}, true},
+		{"new side file", Source{Path: source.Path, Format: FormatAuto, EDSMStationsPath: source.Path}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reason, err := staleReason(header, test.source)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stale := reason != ""; stale != test.stale {
+				t.Errorf("stale %v (%q), want %v", stale, reason, test.stale)
+			}
+		})
+	}
+}
+
+func TestReadCacheRejectsBadRecordCounts(t *testing.T) {
+	for _, count := range []int{-1, 1 << 40} {
+		var content bytes.Buffer
+		content.Write(cacheMagic)
+		if err := gob.NewEncoder(&content).Encode(CacheHeader{SchemaVersion: CacheSchemaVersion, RecordCount: count}); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(t.TempDir(), "system_cache.bin")
+		if err := os.WriteFile(path, content.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := readCache(path); !errors.Is(err, ErrCacheCorrupt) {
+			t.Errorf("record count %d: got %v, want ErrCacheCorrupt", count, err)
+		}
+	}
+}
@@ -1,47 +1,93 @@
 package dataBuilder
 
 import (
-	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
+	"hash"
 	"log"
 	"massacre-finder/args"
-	"math"
 	"os"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type EliteSystemStation struct {
-	Distance       float32 `json:"distance"`
-	Type           string  `json:"type"`
-	PrimaryEconomy string  `json:"primaryEconomy"`
+	Name           string      `json:"name,omitempty"`
+	Distance       float32     `json:"distance"`
+	Type           string      `json:"type"`
+	PrimaryEconomy string      `json:"primaryEconomy"`
+	LandingPads    LandingPads `json:"landingPads"`
+	MaxLandingPad  string      `json:"maxLandingPad"` // "S", "M" or "L"
+	BodyName       string      `json:"bodyName,omitempty"`
+	IsPlanetary    bool        `json:"isPlanetary"`
+}
+
+// LandingPads counts the landing pads of a station by size.
+type LandingPads struct {
+	Small  int `json:"small"`
+	Medium int `json:"medium"`
+	Large  int `json:"large"`
+}
+
+// MaxSize returns the size of the largest pad as "S", "M" or "L", or "" if there are no pads.
+func (p LandingPads) MaxSize() string {
+	switch {
+	case p.Large > 0:
+		return "L"
+	case p.Medium > 0:
+		return "M"
+	case p.Small > 0:
+		return "S"
+	default:
+		return ""
+	}
 }
 
 type EliteSystem struct {
-	Id                     uint64               `json:"id,omitempty"`
-	Name                   string               `json:"name,omitempty"`
-	X                      float32              `json:"x,omitempty"`
-	Y                      float32              `json:"y,omitempty"`
-	Z                      float32              `json:"z,omitempty"`
-	AnarchyFactionCount    int8                 `json:"anarchyFactionCount,omitempty"`
-	NonAnarchyFactionCount int8                 `json:"nonAnarchyFactionCount,omitempty"`
-	RingQty                int8                 `json:"ringQty,omitempty"`
-	SystemSecurityLevel    int8                 `json:"systemSecurityLevel,omitempty"`
-	AnarchyFactionNames    []string             `json:"anarchyFactionNames,omitempty"`
-	NonAnarchyFactionNames []string             `json:"nonAnarchyFactionNames,omitempty"`
-	Stations               []EliteSystemStation `json:"stations"`
+	Id                  uint64               `json:"id,omitempty"`
+	Name                string               `json:"name,omitempty"`
+	X                   float32              `json:"x,omitempty"`
+	Y                   float32              `json:"y,omitempty"`
+	Z                   float32              `json:"z,omitempty"`
+	TargetFactionCount  int8                 `json:"targetFactionCount,omitempty"`
+	GiverFactionCount   int8                 `json:"giverFactionCount,omitempty"`
+	RingQty             int8                 `json:"ringQty,omitempty"` // bodies with planetary rings, belts are not counted
+	SystemSecurityLevel int8                 `json:"systemSecurityLevel,omitempty"`
+	TargetFactionNames  []string             `json:"targetFactionNames,omitempty"`
+	GiverFactionNames   []string             `json:"giverFactionNames,omitempty"`
+	Factions            []EliteFaction       `json:"factions,omitempty"`
+	Stations            []EliteSystemStation `json:"stations"`
+	Rings               []EliteRing          `json:"rings,omitempty"`
+	ControllingFaction  string               `json:"controllingFaction,omitempty"`
+	Population          int64                `json:"population,omitempty"`
+	PrimaryEconomy      string               `json:"primaryEconomy,omitempty"`
+	SecondaryEconomy    string               `json:"secondaryEconomy,omitempty"`
+	Allegiance          string               `json:"allegiance,omitempty"`
+	Government          string               `json:"government,omitempty"`
+	ControllingPower    string               `json:"controllingPower,omitempty"`
+	Powers              []string             `json:"powers,omitempty"`
+	PowerState          string               `json:"powerState,omitempty"`
+	RejectedStations    StationRejections    `json:"-"`
 }
-type EliteSector struct {
-	X int `json:"X"`
-	Y int `json:"Y"`
-	Z int `json:"Z"`
+
+// StationRejections counts the stations of a System that are not mission sources, by the first filter they failed.
+type StationRejections struct {
+	Services   int `json:"services"`   // a RequiredServices entry is missing
+	Distance   int `json:"distance"`   // beyond MaxDistanceInLsForStationToBeConsidered
+	Type       int `json:"type"`       // not one of the allowed station types
+	LandingPad int `json:"landingPad"` // no large pad although RequireLargePads is set
 }
 
-func NewEliteSector(x int, y int, z int) *EliteSector {
-	return &EliteSector{X: x, Y: y, Z: z}
+func (r *StationRejections) Add(other StationRejections) {
+	r.Services += other.Services
+	r.Distance += other.Distance
+	r.Type += other.Type
+	r.LandingPad += other.LandingPad
 }
 
 type eliteSystemJSONCoords struct {
@@ -51,8 +97,14 @@ type eliteSystemJSONCoords struct {
 }
 
 type eliteSystemJSONFaction struct {
-	Name       string `json:"Name"`
-	Government string `json:"government"`
+	Name             string    `json:"Name"`
+	Government       string    `json:"government"`
+	Allegiance       string    `json:"allegiance"`
+	Influence        float32   `json:"influence"`
+	State            string    `json:"state"`
+	ActiveStates     stateList `json:"activeStates"`
+	PendingStates    stateList `json:"pendingStates"`
+	RecoveringStates stateList `json:"recoveringStates"`
 }
 
 type eliteSystemJSONBodyRingEntry struct {
@@ -61,222 +113,197 @@ type eliteSystemJSONBodyRingEntry struct {
 }
 
 type eliteSystemStationEntry struct {
-	DistanceToArrival float32 `json:"distanceToArrival"`
-	Type              string  `json:"type"`
-	PrimaryEconomy	  string  `json:"primaryEconomy"`
-	Services		 []string `json:"services"`
+	Name              string       `json:"name"`
+	DistanceToArrival float32      `json:"distanceToArrival"`
+	Type              string       `json:"type"`
+	PrimaryEconomy    string       `json:"primaryEconomy"`
+	Services          []string     `json:"services"`
+	LandingPads       *LandingPads `json:"landingPads"`
 }
 
 type eliteSystemJSONBody struct {
-	Name  string                         `json:"Name"`
-	Type  string                         `json:"type"`
-	Rings []eliteSystemJSONBodyRingEntry `json:"rings"`
-	Stations []eliteSystemStationEntry `json:"stations"`
-}
-
-type EliteSystemJSON struct {
-	Coords   eliteSystemJSONCoords     `json:"coords"`
-	Name     string                    `json:"Name"`
-	Security string                    `json:"security"`
-	Factions []eliteSystemJSONFaction  `json:"factions"`
-	Bodies   []eliteSystemJSONBody     `json:"bodies"`
-	Id       uint64                    `json:"id64"`
-	Stations []eliteSystemStationEntry `json:"stations"`
+	Name              string                         `json:"Name"`
+	Type              string                         `json:"type"`
+	SubType           string                         `json:"subType"`
+	DistanceToArrival float32                        `json:"distanceToArrival"`
+	Rings             []eliteSystemJSONBodyRingEntry `json:"rings"`
+	Stations          []eliteSystemStationEntry      `json:"stations"`
 }
 
-type eliteSystemJSONEntry struct {
-	value EliteSystemJSON
-	next  *eliteSystemJSONEntry
+type eliteSystemJSONControllingFaction struct {
+	Name       string `json:"name"`
+	Allegiance string `json:"allegiance"`
+	Government string `json:"government"`
 }
 
-type eliteSystemJSONList struct {
-	first *eliteSystemJSONEntry
-	head  *eliteSystemJSONEntry
+type EliteSystemJSON struct {
+	Coords             eliteSystemJSONCoords             `json:"coords"`
+	Name               string                            `json:"Name"`
+	Security           string                            `json:"security"`
+	Factions           []eliteSystemJSONFaction          `json:"factions"`
+	Bodies             []eliteSystemJSONBody             `json:"bodies"`
+	Id                 uint64                            `json:"id64"`
+	Stations           []eliteSystemStationEntry         `json:"stations"`
+	Date               string                            `json:"date"`
+	ControllingFaction eliteSystemJSONControllingFaction `json:"controllingFaction"`
+	Population         int64                             `json:"population"`
+	PrimaryEconomy     string                            `json:"primaryEconomy"`
+	SecondaryEconomy   string                            `json:"secondaryEconomy"`
+	Allegiance         string                            `json:"allegiance"`
+	Government         string                            `json:"government"`
+	ControllingPower   string                            `json:"controllingPower"`
+	Powers             []string                          `json:"powers"`
+	PowerState         string                            `json:"powerState"`
 }
 
-// BuildSystemData reads the populated JSON System by System (to reduce RAM usage) and store just the relevant data.
-// Said relevant data will then be returned, namely a Map of Sector-Index to List of Systems in the Sector
-// The Sector-Size is set to 10, which is convenient because the max distance for Massacre Source Systems is 10ly.
-func buildSystemData(filepath string) []EliteSystemJSON {
-	file, err := os.OpenFile(filepath, os.O_RDONLY, os.ModePerm)
+// spanshDateLayout is the layout of the "date" of spansh records, e.g. "2023-01-30 06:23:12+00".
+const spanshDateLayout = "2006-01-02 15:04:05-07"
 
+// UpdatedAt returns when the record was last updated, if the dump provided that.
+func (s EliteSystemJSON) UpdatedAt() (time.Time, bool) {
+	updatedAt, err := time.Parse(spanshDateLayout, s.Date)
 	if err != nil {
-		log.Fatalln(err)
+		return time.Time{}, false
 	}
-	defer file.Close()
-
-	reader := bufio.NewReader(file)
+	return updatedAt, true
+}
 
-	systemsParsedCounter := 0
+// IsPopulated tells populated Systems apart from the unpopulated ones the galaxy delta dumps also contain.
+func (s EliteSystemJSON) IsPopulated() bool {
+	return len(s.Factions) > 0
+}
 
-	allSystems := eliteSystemJSONList{
-		first: nil, head: nil,
+// buildSystemData streams the populated JSON System by System (to reduce RAM usage) and returns the decoded Systems.
+// If digest is not nil, it receives the raw bytes of the dump.
+func buildSystemData(source Source, digest hash.Hash) ([]EliteSystemJSON, error) {
+	systems := make([]EliteSystemJSON, 0)
+	skipped, err := streamSystemData(source, digest, func(system EliteSystemJSON) error {
+		systems = append(systems, system)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	const bufferSize = 30_000_000
-	buffer := [bufferSize]rune{}
-	nexCharPtr := 0
-	currentDepth := 0 // Outer Array is not needed. That's why it's -1
-	isInWrapperArray := false
+	println("Parsed a total of " + strconv.Itoa(len(systems)) + " Systems, skipped " + strconv.Itoa(skipped) + " malformed records.")
 
-	for {
-		if char, _, err := reader.ReadRune(); err != nil {
-			if err == io.EOF {
-				break
-			} else {
-				log.Fatalln(err)
-			}
-		} else {
-			if char == '[' && !isInWrapperArray {
-				isInWrapperArray = true
-				continue
-			} else if char == ']' && currentDepth == 0 {
-				// TODO: Close down
-			}
+	return systems, nil
+}
 
-			if !isInWrapperArray {
-				continue
-			}
+// streamSystemData decodes the dump of source record by record and hands every System to fn.
+// The dump may be compressed, see openSource, and in any format an InputAdapter exists for. Records that are valid
+// JSON but do not fit the schema are logged with their byte offset (in the decompressed stream), skipped and counted.
+func streamSystemData(source Source, digest hash.Hash, fn func(system EliteSystemJSON) error) (int, error) {
+	candidates, err := source.adapters()
+	if err != nil {
+		return 0, err
+	}
 
-			if char == '{' || char == '[' {
-				currentDepth++
-			} else if char == '}' || char == ']' {
-				currentDepth--
-			}
+	file, err := openSource(source.Path, digest)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
 
-			buildString := false
+	skipped := 0
+	var adapter InputAdapter
 
-			if currentDepth == 0 {
-				if nexCharPtr == 0 {
-					continue
-				}
-				// There is Data present. Append the "closing" char and turn into a string
-				buildString = true
+	err = decodeRecordArray(file, func(raw json.RawMessage, offset int64) error {
+		if adapter == nil {
+			var err error
+			if adapter, err = pickAdapter(candidates, raw); err != nil {
+				return &RecordError{Offset: offset, Err: err}
 			}
-
-			buffer[nexCharPtr] = char
-			nexCharPtr++
-
-			if buildString {
-				stringArray := make([]rune, nexCharPtr)
-				for i := 0; i < nexCharPtr; i++ {
-					stringArray[i] = buffer[i]
-				}
-				nexCharPtr = 0
-				asString := string(stringArray)
-
-				var jsonData EliteSystemJSON
-				err := json.Unmarshal([]byte(asString), &jsonData)
-				if err != nil {
-					print(err)
-				} else {
-
-					systemsParsedCounter++
-
-					entry := eliteSystemJSONEntry{
-						value: jsonData,
-						next:  nil,
-					}
-
-					if allSystems.first == nil {
-						allSystems.first = &entry
-						allSystems.head = &entry
-					} else {
-						allSystems.head.next = &entry
-						allSystems.head = &entry
-					}
-				}
+			println("Reading " + source.Path + " as " + adapter.Name() + " dump.")
+			if err := adapter.Prepare(); err != nil {
+				return err
 			}
-
 		}
-	}
-
-	println("Parsed a total of " + strconv.Itoa(systemsParsedCounter) + " Systems. Copying over...")
-
-	// Build Array from Linked List
-	returnArray := make([]EliteSystemJSON, systemsParsedCounter)
 
-	index := 0
-	pointer := allSystems.first
-	for {
-		if pointer == nil {
-			break
+		jsonData, err := adapter.Convert(raw)
+		if err != nil {
+			log.Println(source.Path+":", &RecordError{Offset: offset, Err: err})
+			skipped++
+			return nil
 		}
-		returnArray[index] = pointer.value
-		index++
-		pointer = pointer.next
+		return fn(jsonData)
+	})
+	if err == nil {
+		err = file.drain()
+	}
+	if err != nil {
+		return skipped, fmt.Errorf("%s: %w", source.Path, err)
 	}
 
-	return returnArray
+	return skipped, nil
 }
 
-func buildCacheFile(cacheFile string, sourceFile string) {
-	if _, err := os.Stat(cacheFile); err == nil {
-		// File Exist, Delete
-		err := os.Remove(cacheFile)
+func buildCacheFile(cacheFile string, source Source) ([]EliteSystemJSON, error) {
+	fingerprint := SourceFingerprint{Path: source.Path, Format: source.Format}
+	if source.Path != StdinPath {
+		stat, err := os.Stat(source.Path)
 		if err != nil {
-			fmt.Println(err)
+			return nil, err
 		}
+		fingerprint.Size = stat.Size()
+		fingerprint.ModTime = stat.ModTime()
 	}
-	newData := buildSystemData(sourceFile)
-
-	jsonString, _ := json.Marshal(newData)
-	ioutil.WriteFile(cacheFile, jsonString, os.ModePerm)
-}
-
-func GetOrCreateSystemData(cachePath string, sourcePath string, forceRebuild bool) []EliteSystemJSON {
-	_, err := os.Stat(cachePath)
-	doesFileExist := err == nil
-
-	isRebuildNeeded := !doesFileExist || forceRebuild
-
-	if isRebuildNeeded {
-		buildCacheFile(cachePath, sourcePath)
+	for _, path := range source.sideFiles() {
+		sideFile, err := fingerprintFile(path)
+		if err != nil {
+			return nil, err
+		}
+		fingerprint.SideFiles = append(fingerprint.SideFiles, sideFile)
 	}
 
-	// and now get the cache
-
-	var data []EliteSystemJSON
-
-	file, _ := ioutil.ReadFile(cachePath)
-
-	json.Unmarshal(file, &data)
+	digest := sha256.New()
+	newData, err := buildSystemData(source, digest)
+	if err != nil {
+		return nil, err
+	}
+	fingerprint.SHA256 = hex.EncodeToString(digest.Sum(nil))
 
-	return data
+	header := CacheHeader{
+		SchemaVersion: CacheSchemaVersion,
+		ToolVersion:   ToolVersion,
+		CreatedAt:     time.Now().UTC(),
+		Source:        fingerprint,
+	}
+	return newData, writeCache(cacheFile, header, newData)
 }
 
-func BuildSectoredData(systemsAsList []EliteSystemJSON, config args.Args) map[EliteSector][]EliteSystem {
-
-	var returnMap = make(map[EliteSector][]EliteSystem)
-
-	for _, entry := range systemsAsList {
-		sector := buildSector(entry.Coords.X, entry.Coords.Y, entry.Coords.Z)
-		listOfSector, hasList := returnMap[sector]
-
-		if hasList {
-			returnMap[sector] = append(listOfSector, buildSystem(entry, config))
+// GetOrCreateSystemData returns the cached system list. The cache is (re)built from source first if there is none yet,
+// forceRebuild is set, or it was built from a different version of the source or with an older schema.
+func GetOrCreateSystemData(cachePath string, source Source, forceRebuild bool) ([]EliteSystemJSON, error) {
+	isRebuildNeeded := forceRebuild
+
+	if !isRebuildNeeded {
+		header, err := ReadCacheHeader(cachePath)
+		if errors.Is(err, os.ErrNotExist) {
+			isRebuildNeeded = true
+		} else if err != nil {
+			return nil, fmt.Errorf("%w (use -rebuild to replace it)", err)
 		} else {
-			returnMap[sector] = []EliteSystem{buildSystem(entry, config)}
+			reason, err := staleReason(header, source)
+			if err != nil {
+				return nil, err
+			}
+			if reason != "" {
+				println("Rebuilding the system cache: " + reason + ".")
+				isRebuildNeeded = true
+			}
 		}
 	}
 
-	return returnMap
-}
-
-func buildSector(x float32, y float32, z float32) EliteSector {
-	const SectorSize = 10
-	_x := int(math.Floor(float64(x / SectorSize)))
-	_y := int(math.Floor(float64(y / SectorSize)))
-	_z := int(math.Floor(float64(z / SectorSize)))
-	return EliteSector{
-		X: _x,
-		Y: _y,
-		Z: _z,
+	if isRebuildNeeded {
+		return buildCacheFile(cachePath, source)
 	}
-}
 
-func BuildSector(system EliteSystem) EliteSector {
-	return buildSector(system.X, system.Y, system.Z)
+	_, data, err := readCache(cachePath)
+	if errors.Is(err, ErrCacheCorrupt) {
+		return nil, fmt.Errorf("%w (use -rebuild to replace it)", err)
+	}
+	return data, err
 }
 
 func buildSystem(data EliteSystemJSON, config args.Args) EliteSystem {
@@ -286,67 +313,78 @@ func buildSystem(data EliteSystemJSON, config args.Args) EliteSystem {
 	returnVal.Z = data.Coords.Z
 	returnVal.Name = data.Name
 	returnVal.Id = data.Id
+	returnVal.ControllingFaction = data.ControllingFaction.Name
+	returnVal.Population = data.Population
+	returnVal.PrimaryEconomy = data.PrimaryEconomy
+	returnVal.SecondaryEconomy = data.SecondaryEconomy
+	returnVal.Allegiance = data.Allegiance
+	returnVal.Government = data.Government
+	returnVal.ControllingPower = data.ControllingPower
+	returnVal.Powers = data.Powers
+	returnVal.PowerState = data.PowerState
 
 	// Calculate Faction Count
-	returnVal.AnarchyFactionCount = 0
-	returnVal.NonAnarchyFactionCount = 0
-	returnVal.NonAnarchyFactionNames = make([]string, 0)
-	returnVal.AnarchyFactionNames = make([]string, 0)
+	returnVal.TargetFactionCount = 0
+	returnVal.GiverFactionCount = 0
+	returnVal.GiverFactionNames = make([]string, 0)
+	returnVal.TargetFactionNames = make([]string, 0)
 
 	jsonStations := make([]eliteSystemStationEntry, 0)
 	jsonStations = append(jsonStations, data.Stations...)
+	stationBodies := make([]string, len(jsonStations))
 
 	for _, body := range data.Bodies {
 		for _, s := range body.Stations {
 			jsonStations = append(jsonStations, s)
+			stationBodies = append(stationBodies, body.Name)
 		}
 	}
 
+	relevantStationTypes := config.StationTypes()
+
 	stations := make([]EliteSystemStation, 0)
-	for _, st := range data.Stations {
-		hasMissionBoard := false
-		for _, service := range st.Services {
-			if service == "Missions" {
-				hasMissionBoard = true
-				break
-			}
-		}
-		if !hasMissionBoard {
+	for i, st := range jsonStations {
+		if !hasAllServices(st.Services, config.RequiredServices) {
+			returnVal.RejectedStations.Services++
 			continue
 		}
 		if st.DistanceToArrival > float32(config.MaxDistanceInLsForStationToBeConsidered) {
+			returnVal.RejectedStations.Distance++
 			continue
 		}
 		/// Station Filter
-		relevantStationTypes := []string{"Outpost", "Coriolis Starport", "Orbis Starport", "Ocellus Starport"}
-
-		if config.ConsiderGroundBases {
-			relevantStationTypes = append(relevantStationTypes, "Planetary Outpost")
-		}
-
-		if config.ConsiderOdysseySettlements {
-			relevantStationTypes = append(relevantStationTypes, "Settlement")
-		}
-
 		isRelevantType := false
 
 		for _, t := range relevantStationTypes {
-			if st.Type == t {
+			if strings.EqualFold(st.Type, t) {
 				isRelevantType = true
 				break
 			}
 		}
 
 		if !isRelevantType {
+			returnVal.RejectedStations.Type++
 			continue
 		}
 
-
+		pads := defaultLandingPads(st.Type)
+		if st.LandingPads != nil {
+			pads = *st.LandingPads
+		}
+		if config.RequireLargePads && pads.Large == 0 {
+			returnVal.RejectedStations.LandingPad++
+			continue
+		}
 
 		newStation := EliteSystemStation{
+			Name:           st.Name,
 			Distance:       st.DistanceToArrival,
 			Type:           st.Type,
 			PrimaryEconomy: st.PrimaryEconomy,
+			LandingPads:    pads,
+			MaxLandingPad:  pads.MaxSize(),
+			BodyName:       stationBodies[i],
+			IsPlanetary:    stationBodies[i] != "" || planetaryStationTypes[st.Type],
 		}
 
 		stations = append(stations, newStation)
@@ -357,14 +395,16 @@ func buildSystem(data EliteSystemJSON, config args.Args) EliteSystem {
 	})
 	returnVal.Stations = stations
 
+	returnVal.Factions = make([]EliteFaction, 0, len(data.Factions))
 	for _, faction := range data.Factions {
-		if faction.Government == "Anarchy" {
-			returnVal.AnarchyFactionCount++
-			returnVal.AnarchyFactionNames = append(returnVal.AnarchyFactionNames, faction.Name)
+		returnVal.Factions = append(returnVal.Factions, buildFaction(faction))
+		if config.TargetGovernments.ContainsFold(faction.Government) {
+			returnVal.TargetFactionCount++
+			returnVal.TargetFactionNames = append(returnVal.TargetFactionNames, faction.Name)
 
 		} else {
-			returnVal.NonAnarchyFactionCount++
-			returnVal.NonAnarchyFactionNames = append(returnVal.NonAnarchyFactionNames, faction.Name)
+			returnVal.GiverFactionCount++
+			returnVal.GiverFactionNames = append(returnVal.GiverFactionNames, faction.Name)
 		}
 	}
 
@@ -385,12 +425,54 @@ func buildSystem(data EliteSystemJSON, config args.Args) EliteSystem {
 		returnVal.SystemSecurityLevel = 127
 	}
 
+	returnVal.Rings = buildRings(data.Bodies)
 	returnVal.RingQty = 0
-	for _, entry := range data.Bodies {
-		if len(entry.Rings) > 0 {
+	ringedBodies := make(map[string]bool)
+	for _, ring := range returnVal.Rings {
+		if !ring.IsBelt && !ringedBodies[ring.BodyName] {
+			ringedBodies[ring.BodyName] = true
 			returnVal.RingQty++
 		}
 	}
 
 	return returnVal
 }
+
+// hasAllServices reports whether every required service is offered, ignoring case.
+func hasAllServices(offered []string, required []string) bool {
+	for _, service := range required {
+		if !containsFold(offered, service) {
+			return false
+		}
+	}
+	return true
+}
+
+// planetaryStationTypes are the station types that sit on the surface of a body.
+var planetaryStationTypes = map[string]bool{
+	args.StationPlanetaryOutpost: true,
+	args.StationPlanetaryPort:    true,
+	args.StationSettlement:       true,
+}
+
+// defaultLandingPads is used for stations the dump has no pad data for. The counts are typical for the type,
+// what matters most is the largest pad size.
+func defaultLandingPads(stationType string) LandingPads {
+	switch stationType {
+	case args.StationOutpost:
+		return LandingPads{Small: 2, Medium: 1}
+	case args.StationCoriolis, args.StationOrbis, args.StationOcellus, args.StationAsteroidBase:
+		return LandingPads{Small: 17, Medium: 18, Large: 9}
+	case args.StationPlanetaryPort:
+		return LandingPads{Small: 8, Medium: 12, Large: 7}
+	case args.StationPlanetaryOutpost:
+		return LandingPads{Small: 2, Medium: 2, Large: 2}
+	case args.StationFleetCarrier:
+		return LandingPads{Small: 4, Medium: 4, Large: 8}
+	case args.StationMegaship:
+		return LandingPads{Small: 4, Medium: 2, Large: 4}
+	default:
+		// Settlements vary from a single small pad to several large ones, assume the worst.
+		return LandingPads{Small: 1}
+	}
+}
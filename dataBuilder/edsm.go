@@ -0,0 +1,267 @@
+package dataBuilder
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+)
+
+// edsmAdapter reads the nightly systemsPopulated.json dump from edsm.net. Stations and bodies are
+// merged in from the separate stations.json and bodies dumps, which are indexed by system on first use.
+type edsmAdapter struct {
+	stationsPath string
+	bodiesPath   string
+
+	loaded   bool
+	stations map[uint64][]edsmStation
+	bodies   map[uint64][]edsmBody
+}
+
+type edsmCoords struct {
+	X float32 `json:"x"`
+	Y float32 `json:"y"`
+	Z float32 `json:"z"`
+}
+
+type edsmFaction struct {
+	Name             string    `json:"name"`
+	Government       string    `json:"government"`
+	Allegiance       string    `json:"allegiance"`
+	Influence        float32   `json:"influence"`
+	State            string    `json:"state"`
+	ActiveStates     stateList `json:"activeStates"`
+	PendingStates    stateList `json:"pendingStates"`
+	RecoveringStates stateList `json:"recoveringStates"`
+}
+
+type edsmControllingFaction struct {
+	Name       string `json:"name"`
+	Allegiance string `json:"allegiance"`
+	Government string `json:"government"`
+}
+
+type edsmSystem struct {
+	Id64               uint64                 `json:"id64"`
+	Name               string                 `json:"name"`
+	Coords             edsmCoords             `json:"coords"`
+	Security           string                 `json:"security"`
+	Factions           []edsmFaction          `json:"factions"`
+	UpdateTime         string                 `json:"updateTime"`
+	ControllingFaction edsmControllingFaction `json:"controllingFaction"`
+	Population         int64                  `json:"population"`
+	Economy            string                 `json:"economy"`
+	SecondEconomy      string                 `json:"secondEconomy"`
+	Allegiance         string                 `json:"allegiance"`
+	Government         string                 `json:"government"`
+}
+
+// edsmDateLayout is the layout of EDSM timestamps, which are UTC.
+const edsmDateLayout = "2006-01-02 15:04:05"
+
+type edsmStationBody struct {
+	Name string `json:"name"`
+}
+
+type edsmStation struct {
+	Name              string           `json:"name"`
+	SystemId64        uint64           `json:"systemId64"`
+	Type              string           `json:"type"`
+	DistanceToArrival float32          `json:"distanceToArrival"`
+	Economy           string           `json:"economy"`
+	HaveMarket        bool             `json:"haveMarket"`
+	HaveShipyard      bool             `json:"haveShipyard"`
+	HaveOutfitting    bool             `json:"haveOutfitting"`
+	OtherServices     []string         `json:"otherServices"`
+	Body              *edsmStationBody `json:"body"`
+}
+
+type edsmRing struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type edsmBody struct {
+	SystemId64        uint64     `json:"systemId64"`
+	Name              string     `json:"name"`
+	Type              string     `json:"type"`
+	SubType           string     `json:"subType"`
+	DistanceToArrival float32    `json:"distanceToArrival"`
+	Rings             []edsmRing `json:"rings"`
+}
+
+// edsmStationTypes maps the EDSM station types that are named differently by spansh.
+var edsmStationTypes = map[string]string{
+	"Odyssey Settlement": "Settlement",
+	"Fleet Carrier":      "Drake-Class Carrier",
+}
+
+func (a *edsmAdapter) Name() string {
+	return FormatEDSM
+}
+
+// Detect tells EDSM records apart by their numeric EDSM "id" next to the "id64", spansh records
+// have no "id" but carry their "bodies" inline.
+func (a *edsmAdapter) Detect(raw json.RawMessage) bool {
+	keys := recordKeys(raw)
+	_, hasId := keys["id"]
+	_, hasBodies := keys["bodies"]
+	return hasId && !hasBodies
+}
+
+func (a *edsmAdapter) Convert(raw json.RawMessage) (EliteSystemJSON, error) {
+	var system edsmSystem
+	if err := json.Unmarshal(raw, &system); err != nil {
+		return EliteSystemJSON{}, err
+	}
+
+	converted := EliteSystemJSON{
+		Coords:   eliteSystemJSONCoords{X: system.Coords.X, Y: system.Coords.Y, Z: system.Coords.Z},
+		Name:     system.Name,
+		Security: system.Security,
+		Id:       system.Id64,
+		Factions: make([]eliteSystemJSONFaction, 0, len(system.Factions)),
+		Bodies:   make([]eliteSystemJSONBody, 0),
+		Stations: make([]eliteSystemStationEntry, 0),
+
+		ControllingFaction: eliteSystemJSONControllingFaction(system.ControllingFaction),
+		Population:         system.Population,
+		PrimaryEconomy:     system.Economy,
+		SecondaryEconomy:   system.SecondEconomy,
+		Allegiance:         system.Allegiance,
+		Government:         system.Government,
+		// EDSM has no powerplay data.
+	}
+
+	if updatedAt, err := time.Parse(edsmDateLayout, system.UpdateTime); err == nil {
+		converted.Date = updatedAt.Format(spanshDateLayout)
+	}
+
+	for _, faction := range system.Factions {
+		converted.Factions = append(converted.Factions, eliteSystemJSONFaction(faction))
+	}
+
+	bodyIndex := make(map[string]int)
+	for _, body := range a.bodies[system.Id64] {
+		rings := make([]eliteSystemJSONBodyRingEntry, 0, len(body.Rings))
+		for _, ring := range body.Rings {
+			rings = append(rings, eliteSystemJSONBodyRingEntry{Name: ring.Name, Type: ring.Type})
+		}
+		bodyIndex[body.Name] = len(converted.Bodies)
+		converted.Bodies = append(converted.Bodies, eliteSystemJSONBody{
+			Name:              body.Name,
+			Type:              body.Type,
+			SubType:           body.SubType,
+			DistanceToArrival: body.DistanceToArrival,
+			Rings:             rings,
+		})
+	}
+
+	// Like spansh, stations on a body are attached to it and orbital ones to the system.
+	for _, station := range a.stations[system.Id64] {
+		entry := station.toSpansh()
+		if station.Body == nil || station.Body.Name == "" {
+			converted.Stations = append(converted.Stations, entry)
+			continue
+		}
+		index, known := bodyIndex[station.Body.Name]
+		if !known {
+			index = len(converted.Bodies)
+			bodyIndex[station.Body.Name] = index
+			converted.Bodies = append(converted.Bodies, eliteSystemJSONBody{Name: station.Body.Name})
+		}
+		converted.Bodies[index].Stations = append(converted.Bodies[index].Stations, entry)
+	}
+
+	return converted, nil
+}
+
+func (s edsmStation) toSpansh() eliteSystemStationEntry {
+	services := make([]string, 0, len(s.OtherServices)+3)
+	if s.HaveMarket {
+		services = append(services, "Market")
+	}
+	if s.HaveShipyard {
+		services = append(services, "Shipyard")
+	}
+	if s.HaveOutfitting {
+		services = append(services, "Outfitting")
+	}
+	services = append(services, s.OtherServices...)
+
+	stationType := s.Type
+	if mapped, ok := edsmStationTypes[stationType]; ok {
+		stationType = mapped
+	}
+
+	// EDSM has no pad data, buildSystem falls back to the usual pads of the type.
+	return eliteSystemStationEntry{
+		Name:              s.Name,
+		DistanceToArrival: s.DistanceToArrival,
+		Type:              stationType,
+		PrimaryEconomy:    s.Economy,
+		Services:          services,
+	}
+}
+
+// Prepare indexes the stations and bodies dumps by system. Both are streamed, so only the relevant fields are kept.
+func (a *edsmAdapter) Prepare() error {
+	if a.loaded {
+		return nil
+	}
+	a.stations = make(map[uint64][]edsmStation)
+	a.bodies = make(map[uint64][]edsmBody)
+
+	if a.stationsPath != "" {
+		err := streamSideFile(a.stationsPath, func(raw json.RawMessage) error {
+			var station edsmStation
+			if err := json.Unmarshal(raw, &station); err != nil {
+				return err
+			}
+			a.stations[station.SystemId64] = append(a.stations[station.SystemId64], station)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if a.bodiesPath != "" {
+		err := streamSideFile(a.bodiesPath, func(raw json.RawMessage) error {
+			var body edsmBody
+			if err := json.Unmarshal(raw, &body); err != nil {
+				return err
+			}
+			// Only ringed bodies are of interest, this keeps the index small.
+			if len(body.Rings) > 0 {
+				a.bodies[body.SystemId64] = append(a.bodies[body.SystemId64], body)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	a.loaded = true
+	return nil
+}
+
+func streamSideFile(path string, fn func(raw json.RawMessage) error) error {
+	file, err := openSource(path, nil)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = decodeRecordArray(file, func(raw json.RawMessage, offset int64) error {
+		if err := fn(raw); err != nil {
+			log.Println(path+":", &RecordError{Offset: offset, Err: err})
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package dataBuilder
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// EliteFaction is a minor faction as present in one System.
+type EliteFaction struct {
+	Name             string   `json:"name"`
+	Government       string   `json:"government"`
+	Allegiance       string   `json:"allegiance,omitempty"`
+	Influence        float32  `json:"influence"` // 0..1
+	ActiveStates     []string `json:"activeStates,omitempty"`
+	PendingStates    []string `json:"pendingStates,omitempty"`
+	RecoveringStates []string `json:"recoveringStates,omitempty"`
+}
+
+// HasActiveState reports whether one of the given states (e.g. "Retreat", "War") is active, ignoring case.
+func (f EliteFaction) HasActiveState(states []string) bool {
+	for _, active := range f.ActiveStates {
+		for _, state := range states {
+			if strings.EqualFold(active, state) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FindFaction returns the faction of the System with the given name.
+func (s EliteSystem) FindFaction(name string) (EliteFaction, bool) {
+	for _, faction := range s.Factions {
+		if faction.Name == name {
+			return faction, true
+		}
+	}
+	return EliteFaction{}, false
+}
+
+// stateList decodes faction states given either as plain names (spansh) or as {"state": name, ...} objects (EDSM).
+type stateList []string
+
+func (l *stateList) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	states := make(stateList, 0, len(raw))
+	for _, entry := range raw {
+		var name string
+		if err := json.Unmarshal(entry, &name); err != nil {
+			var object struct {
+				State string `json:"state"`
+			}
+			if err := json.Unmarshal(entry, &object); err != nil {
+				return err
+			}
+			name = object.State
+		}
+		if name != "" && name != "None" {
+			states = append(states, name)
+		}
+	}
+	*l = states
+	return nil
+}
+
+func buildFaction(data eliteSystemJSONFaction) EliteFaction {
+	active := make([]string, 0, len(data.ActiveStates)+1)
+	active = append(active, data.ActiveStates...)
+	// Older dumps only carry the single dominant state.
+	if data.State != "" && data.State != "None" && !containsFold(active, data.State) {
+		active = append(active, data.State)
+	}
+
+	return EliteFaction{
+		Name:             data.Name,
+		Government:       data.Government,
+		Allegiance:       data.Allegiance,
+		Influence:        data.Influence,
+		ActiveStates:     active,
+		PendingStates:    data.PendingStates,
+		RecoveringStates: data.RecoveringStates,
+	}
+}
+
+func containsFold(list []string, value string) bool {
+	for _, entry := range list {
+		if strings.EqualFold(entry, value) {
+			return true
+		}
+	}
+	return false
+}
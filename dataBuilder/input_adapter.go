@@ -0,0 +1,97 @@
+package dataBuilder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Names of the supported input formats.
+const (
+	FormatAuto   = "auto"
+	FormatSpansh = "spansh"
+	FormatEDSM   = "edsm"
+)
+
+// InputAdapter converts the records of one galaxy dump format into EliteSystemJSON, the spansh shaped
+// intermediate record that the cache holds and everything after parsing works on.
+type InputAdapter interface {
+	Name() string
+	// Detect reports whether the first record of a dump looks like this format.
+	Detect(raw json.RawMessage) bool
+	// Prepare is called once the adapter is picked, before the first record is converted. An error aborts the
+	// whole build, unlike Convert errors that only skip a record.
+	Prepare() error
+	Convert(raw json.RawMessage) (EliteSystemJSON, error)
+}
+
+// Source describes where the galaxy dump comes from and how to read it.
+type Source struct {
+	Path   string
+	Format string // one of FormatAuto, FormatSpansh or FormatEDSM
+	// EDSM ships stations and bodies in separate dumps, both are optional.
+	EDSMStationsPath string
+	EDSMBodiesPath   string
+}
+
+// adapters returns the adapters that may be used for the source, in detection order.
+func (s Source) adapters() ([]InputAdapter, error) {
+	spansh := &spanshAdapter{}
+	edsm := &edsmAdapter{stationsPath: s.EDSMStationsPath, bodiesPath: s.EDSMBodiesPath}
+
+	switch s.Format {
+	case "", FormatAuto:
+		return []InputAdapter{edsm, spansh}, nil
+	case FormatSpansh:
+		return []InputAdapter{spansh}, nil
+	case FormatEDSM:
+		return []InputAdapter{edsm}, nil
+	default:
+		return nil, fmt.Errorf("unknown input format \"%s\", use %s, %s or %s", s.Format, FormatAuto, FormatSpansh, FormatEDSM)
+	}
+}
+
+// pickAdapter returns the first adapter that recognises the record. A single candidate is used without asking,
+// so that an explicitly selected format reports schema errors instead of "unknown format".
+func pickAdapter(candidates []InputAdapter, raw json.RawMessage) (InputAdapter, error) {
+	if len(candidates) == 1 {
+		return candidates[0], nil
+	}
+	for _, adapter := range candidates {
+		if adapter.Detect(raw) {
+			return adapter, nil
+		}
+	}
+	return nil, errors.New("could not detect the format of the dump from its first record")
+}
+
+// recordKeys returns the top level keys of a JSON object, or nil if raw is no object.
+func recordKeys(raw json.RawMessage) map[string]json.RawMessage {
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return nil
+	}
+	return keys
+}
+
+// spanshAdapter reads galaxy_populated.json and the galaxy_*.json dumps from spansh.co.uk.
+type spanshAdapter struct{}
+
+func (a *spanshAdapter) Name() string {
+	return FormatSpansh
+}
+
+func (a *spanshAdapter) Prepare() error {
+	return nil
+}
+
+func (a *spanshAdapter) Detect(raw json.RawMessage) bool {
+	_, hasId64 := recordKeys(raw)["id64"]
+	return hasId64
+}
+
+func (a *spanshAdapter) Convert(raw json.RawMessage) (EliteSystemJSON, error) {
+	var system EliteSystemJSON
+	err := json.Unmarshal(raw, &system)
+	return system, err
+}
@@ -0,0 +1,61 @@
+package dataBuilder
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// RecordError describes a record of the galaxy dump that could not be decoded.
+type RecordError struct {
+	Offset int64 // byte offset of the start of the record
+	Err    error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("record at byte %d: %v", e.Offset, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+// decodeRecordArray streams the top level JSON array in r and hands every element to fn as raw JSON,
+// together with the byte offset it starts at. Only one element is held in memory at a time.
+// Syntax errors abort the stream, as there is no reliable way to find the start of the next record.
+func decodeRecordArray(r io.Reader, fn func(raw json.RawMessage, offset int64) error) error {
+	decoder := json.NewDecoder(r)
+
+	token, err := decoder.Token()
+	if err != nil {
+		return &RecordError{Offset: errorOffset(decoder, err), Err: err}
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '[' {
+		return &RecordError{Offset: 0, Err: errors.New("expected the dump to be a JSON array")}
+	}
+
+	for decoder.More() {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return &RecordError{Offset: errorOffset(decoder, err), Err: err}
+		}
+		// The raw message holds the exact bytes of the record, which ends at the current offset.
+		if err := fn(raw, decoder.InputOffset()-int64(len(raw))); err != nil {
+			return err
+		}
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return &RecordError{Offset: errorOffset(decoder, err), Err: err}
+	}
+	return nil
+}
+
+func errorOffset(decoder *json.Decoder, err error) int64 {
+	var syntaxError *json.SyntaxError
+	if errors.As(err, &syntaxError) {
+		return syntaxError.Offset
+	}
+	return decoder.InputOffset()
+}
@@ -0,0 +1,37 @@
+package dataBuilder
+
+import "strings"
+
+// EliteRing is a planetary ring or an asteroid belt of a body in the System.
+type EliteRing struct {
+	Name              string  `json:"name"`
+	Type              string  `json:"type"` // "Metallic", "Metal Rich", "Rocky" or "Icy"
+	BodyName          string  `json:"bodyName"`
+	BodyType          string  `json:"bodyType"`    // "Star" or "Planet"
+	BodySubType       string  `json:"bodySubType"` // e.g. "Class I gas giant"
+	DistanceToArrival float32 `json:"distanceToArrival"`
+	IsBelt            bool    `json:"isBelt"` // asteroid belt around a star, no Resource Extraction Sites
+}
+
+// IsGasGiant reports whether the ring orbits a gas giant.
+func (r EliteRing) IsGasGiant() bool {
+	return strings.Contains(strings.ToLower(r.BodySubType), "gas giant")
+}
+
+func buildRings(bodies []eliteSystemJSONBody) []EliteRing {
+	rings := make([]EliteRing, 0)
+	for _, body := range bodies {
+		for _, ring := range body.Rings {
+			rings = append(rings, EliteRing{
+				Name:              ring.Name,
+				Type:              ring.Type,
+				BodyName:          body.Name,
+				BodyType:          body.Type,
+				BodySubType:       body.SubType,
+				DistanceToArrival: body.DistanceToArrival,
+				IsBelt:            body.Type == "Star" || strings.HasSuffix(ring.Name, " Belt"),
+			})
+		}
+	}
+	return rings
+}
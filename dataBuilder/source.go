@@ -0,0 +1,95 @@
+package dataBuilder
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"hash"
+	"io"
+	"os"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// StdinPath is the source path that makes the galaxy dump be read from stdin.
+const StdinPath = "-"
+
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// openSource opens the galaxy dump at path (or stdin for StdinPath) and transparently decompresses
+// gzip, bzip2 and zstd, detected by their magic bytes rather than the file extension.
+// If digest is not nil, the raw (still compressed) bytes are fed into it while they are read.
+func openSource(path string, digest hash.Hash) (*sourceReader, error) {
+	var file *os.File
+	if path == StdinPath {
+		file = os.Stdin
+	} else {
+		var err error
+		file, err = os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var raw io.Reader = file
+	if digest != nil {
+		raw = io.TeeReader(file, digest)
+	}
+
+	buffered := bufio.NewReaderSize(raw, 1<<20)
+	// A short read only means that the input is smaller than the magic, which then simply does not match.
+	magic, err := buffered.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		file.Close()
+		return nil, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		reader, err := gzip.NewReader(buffered)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return &sourceReader{raw: raw, Reader: reader, closers: []io.Closer{reader, file}}, nil
+	case bytes.HasPrefix(magic, bzip2Magic):
+		return &sourceReader{raw: raw, Reader: bzip2.NewReader(buffered), closers: []io.Closer{file}}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		decoder, err := zstd.NewReader(buffered)
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		return &sourceReader{raw: raw, Reader: decoder, closers: []io.Closer{decoder.IOReadCloser(), file}}, nil
+	default:
+		return &sourceReader{raw: raw, Reader: buffered, closers: []io.Closer{file}}, nil
+	}
+}
+
+// sourceReader closes the decompressor and the underlying file together.
+type sourceReader struct {
+	io.Reader
+	raw     io.Reader
+	closers []io.Closer
+}
+
+// drain reads the rest of the raw input, e.g. whitespace after the JSON array, so that a digest covers all of it.
+func (s *sourceReader) drain() error {
+	_, err := io.Copy(io.Discard, s.raw)
+	return err
+}
+
+func (s *sourceReader) Close() error {
+	var firstErr error
+	for _, closer := range s.closers {
+		if err := closer.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
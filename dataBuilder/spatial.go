@@ -0,0 +1,70 @@
+package dataBuilder
+
+import "sort"
+
+// SpatialIndex finds the Systems within a radius of a point. Unlike fixed size sectors it has no upper bound
+// on the radius, so other mission radii can be used without missing Systems.
+type SpatialIndex interface {
+	// Within calls fn for every indexed System within radius ly of center, center itself included.
+	Within(center EliteSystem, radius float32, fn func(system EliteSystem))
+}
+
+// KDTree is a static 3-d tree over System coordinates, built once and only read afterwards.
+type KDTree struct {
+	systems []EliteSystem // in tree order, the median of every range is its node
+}
+
+// NewKDTree builds the tree, the order of systems is changed in place.
+func NewKDTree(systems []EliteSystem) *KDTree {
+	tree := &KDTree{systems: systems}
+	tree.build(0, len(systems), 0)
+	return tree
+}
+
+func (t *KDTree) build(from int, to int, axis int) {
+	if to-from <= 1 {
+		return
+	}
+	part := t.systems[from:to]
+	sort.Slice(part, func(i, j int) bool { return coordinate(part[i], axis) < coordinate(part[j], axis) })
+	median := from + (to-from)/2
+	next := (axis + 1) % 3
+	t.build(from, median, next)
+	t.build(median+1, to, next)
+}
+
+func (t *KDTree) Within(center EliteSystem, radius float32, fn func(system EliteSystem)) {
+	t.within(0, len(t.systems), 0, center, radius, radius*radius, fn)
+}
+
+func (t *KDTree) within(from int, to int, axis int, center EliteSystem, radius float32, radiusSquared float32, fn func(system EliteSystem)) {
+	if from >= to {
+		return
+	}
+	median := from + (to-from)/2
+	node := t.systems[median]
+	if DistanceSquared(node, center) <= radiusSquared {
+		fn(node)
+	}
+
+	delta := coordinate(center, axis) - coordinate(node, axis)
+	next := (axis + 1) % 3
+	// Only descend into a half if the sphere reaches across the splitting plane.
+	if delta-radius <= 0 {
+		t.within(from, median, next, center, radius, radiusSquared, fn)
+	}
+	if delta+radius >= 0 {
+		t.within(median+1, to, next, center, radius, radiusSquared, fn)
+	}
+}
+
+func coordinate(system EliteSystem, axis int) float32 {
+	switch axis {
+	case 0:
+		return system.X
+	case 1:
+		return system.Y
+	default:
+		return system.Z
+	}
+}
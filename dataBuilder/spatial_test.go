@@ -0,0 +1,55 @@
+package dataBuilder
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestKDTreeWithinMatchesLinearScan(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	randomCoordinate := func() float32 { return random.Float32()*200 - 100 }
+
+	systems := make([]EliteSystem, 2000)
+	for i := range systems {
+		systems[i] = EliteSystem{Id: uint64(i + 1), X: randomCoordinate(), Y: randomCoordinate(), Z: randomCoordinate()}
+	}
+	// Systems on the same splitting plane must not be lost.
+	systems[1] = EliteSystem{Id: 2, X: systems[0].X, Y: systems[0].Y, Z: systems[0].Z + 3}
+	systems[2] = EliteSystem{Id: 3, X: systems[0].X, Y: systems[0].Y - 5, Z: systems[0].Z}
+
+	linear := append([]EliteSystem(nil), systems...)
+	tree := NewKDTree(systems)
+
+	for _, radius := range []float32{0, 1, 5, 10, 25, 300} {
+		for i := 0; i < 50; i++ {
+			center := linear[random.Intn(len(linear))]
+			if i%2 == 1 {
+				// A point that is not a system itself.
+				center = EliteSystem{X: randomCoordinate(), Y: randomCoordinate(), Z: randomCoordinate()}
+			}
+
+			var want []uint64
+			for _, system := range linear {
+				if DistanceSquared(system, center) <= radius*radius {
+					want = append(want, system.Id)
+				}
+			}
+			var got []uint64
+			tree.Within(center, radius, func(system EliteSystem) { got = append(got, system.Id) })
+
+			slices.Sort(want)
+			slices.Sort(got)
+			if !slices.Equal(got, want) {
+				t.Fatalf("Within(%v, %g) found %d systems %v, linear scan %d systems %v", center, radius, len(got), got, len(want), want)
+			}
+		}
+	}
+}
+
+func TestKDTreeWithinEmpty(t *testing.T) {
+	tree := NewKDTree(nil)
+	tree.Within(EliteSystem{}, 10, func(system EliteSystem) {
+		t.Errorf("unexpected system %v in an empty tree", system)
+	})
+}
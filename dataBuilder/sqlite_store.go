@@ -0,0 +1,308 @@
+package dataBuilder
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"massacre-finder/args"
+	"os"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+// The schema is meant to be queried by other tools as well. Every System keeps its full record as JSON
+// (usable with json_extract), the columns and child tables hold the parts that are commonly filtered on.
+const sqliteSchema = `
+CREATE TABLE meta (
+	key   TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+);
+CREATE TABLE systems (
+	id64     INTEGER PRIMARY KEY,
+	name     TEXT NOT NULL,
+	x        REAL NOT NULL,
+	y        REAL NOT NULL,
+	z        REAL NOT NULL,
+	security TEXT NOT NULL,
+	date     TEXT NOT NULL,
+	record   TEXT NOT NULL,
+
+	controlling_faction TEXT NOT NULL,
+	population          INTEGER NOT NULL,
+	primary_economy     TEXT NOT NULL,
+	secondary_economy   TEXT NOT NULL,
+	allegiance          TEXT NOT NULL,
+	government          TEXT NOT NULL,
+	controlling_power   TEXT NOT NULL,
+	power_state         TEXT NOT NULL
+);
+CREATE INDEX systems_name ON systems (name COLLATE NOCASE);
+CREATE VIRTUAL TABLE systems_rtree USING rtree (id64, min_x, max_x, min_y, max_y, min_z, max_z);
+CREATE TABLE factions (
+	system_id64       INTEGER NOT NULL REFERENCES systems (id64),
+	name              TEXT NOT NULL,
+	government        TEXT NOT NULL,
+	allegiance        TEXT NOT NULL,
+	influence         REAL NOT NULL,
+	active_states     TEXT NOT NULL, -- comma separated
+	pending_states    TEXT NOT NULL,
+	recovering_states TEXT NOT NULL
+);
+CREATE INDEX factions_system ON factions (system_id64);
+CREATE INDEX factions_name ON factions (name);
+CREATE TABLE stations (
+	system_id64         INTEGER NOT NULL REFERENCES systems (id64),
+	name                TEXT NOT NULL,
+	body_name           TEXT,
+	type                TEXT NOT NULL,
+	distance_to_arrival REAL NOT NULL,
+	primary_economy     TEXT NOT NULL,
+	services            TEXT NOT NULL, -- comma separated
+	pads_small          INTEGER,       -- NULL if the dump has no pad data
+	pads_medium         INTEGER,
+	pads_large          INTEGER
+);
+CREATE INDEX stations_system ON stations (system_id64);
+CREATE TABLE rings (
+	system_id64         INTEGER NOT NULL REFERENCES systems (id64),
+	body_name           TEXT NOT NULL,
+	body_type           TEXT NOT NULL,
+	body_sub_type       TEXT NOT NULL,
+	distance_to_arrival REAL NOT NULL,
+	name                TEXT NOT NULL,
+	type                TEXT NOT NULL,
+	is_belt             INTEGER NOT NULL
+);
+CREATE INDEX rings_system ON rings (system_id64);
+`
+
+// BuildSQLiteStore writes systems into a new SQLite database at dbPath, replacing an existing one.
+func BuildSQLiteStore(dbPath string, header CacheHeader, systems []EliteSystemJSON) error {
+	if err := os.Remove(dbPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		return fmt.Errorf("%s: %w", dbPath, err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	meta := map[string]string{
+		"cache_schema_version": fmt.Sprint(header.SchemaVersion),
+		"tool_version":         ToolVersion,
+		"source_path":          header.Source.Path,
+		"source_sha256":        header.Source.SHA256,
+		"last_update":          header.LastUpdate.Format(spanshDateLayout),
+	}
+	for key, value := range meta {
+		if _, err := tx.Exec(`INSERT INTO meta (key, value) VALUES (?, ?)`, key, value); err != nil {
+			return err
+		}
+	}
+
+	insertSystem, err := tx.Prepare(`INSERT INTO systems (id64, name, x, y, z, security, date, record,
+		controlling_faction, population, primary_economy, secondary_economy, allegiance, government, controlling_power, power_state)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	insertPoint, err := tx.Prepare(`INSERT INTO systems_rtree (id64, min_x, max_x, min_y, max_y, min_z, max_z) VALUES (?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	insertFaction, err := tx.Prepare(`INSERT INTO factions (system_id64, name, government, allegiance, influence, active_states, pending_states, recovering_states) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	insertStation, err := tx.Prepare(`INSERT INTO stations (system_id64, name, body_name, type, distance_to_arrival, primary_economy, services, pads_small, pads_medium, pads_large) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	insertRing, err := tx.Prepare(`INSERT INTO rings (system_id64, body_name, body_type, body_sub_type, distance_to_arrival, name, type, is_belt) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+
+	for _, system := range systems {
+		record, err := json.Marshal(system)
+		if err != nil {
+			return err
+		}
+		c := system.Coords
+		_, err = insertSystem.Exec(system.Id, system.Name, c.X, c.Y, c.Z, system.Security, system.Date, string(record),
+			system.ControllingFaction.Name, system.Population, system.PrimaryEconomy, system.SecondaryEconomy,
+			system.Allegiance, system.Government, system.ControllingPower, system.PowerState)
+		if err != nil {
+			return fmt.Errorf("system %s: %w", system.Name, err)
+		}
+		if _, err := insertPoint.Exec(system.Id, c.X, c.X, c.Y, c.Y, c.Z, c.Z); err != nil {
+			return fmt.Errorf("system %s: %w", system.Name, err)
+		}
+
+		for _, data := range system.Factions {
+			faction := buildFaction(data)
+			_, err := insertFaction.Exec(system.Id, faction.Name, faction.Government, faction.Allegiance, faction.Influence,
+				strings.Join(faction.ActiveStates, ","), strings.Join(faction.PendingStates, ","), strings.Join(faction.RecoveringStates, ","))
+			if err != nil {
+				return err
+			}
+		}
+		for _, station := range system.Stations {
+			if err := insertStationRow(insertStation, system.Id, nil, station); err != nil {
+				return err
+			}
+		}
+		for _, body := range system.Bodies {
+			for _, station := range body.Stations {
+				if err := insertStationRow(insertStation, system.Id, body.Name, station); err != nil {
+					return err
+				}
+			}
+		}
+		for _, ring := range buildRings(system.Bodies) {
+			_, err := insertRing.Exec(system.Id, ring.BodyName, ring.BodyType, ring.BodySubType, ring.DistanceToArrival, ring.Name, ring.Type, ring.IsBelt)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+func insertStationRow(insert *sql.Stmt, systemId uint64, bodyName interface{}, station eliteSystemStationEntry) error {
+	var small, medium, large interface{}
+	if station.LandingPads != nil {
+		small, medium, large = station.LandingPads.Small, station.LandingPads.Medium, station.LandingPads.Large
+	}
+	_, err := insert.Exec(systemId, station.Name, bodyName, station.Type, station.DistanceToArrival, station.PrimaryEconomy,
+		strings.Join(station.Services, ","), small, medium, large)
+	return err
+}
+
+// SQLiteStore is a SystemStore backed by a database written by BuildSQLiteStore. Systems are read when they are
+// asked for and not kept, so the bubble never has to fit into memory. The price is that every neighbour lookup
+// decodes and builds the Systems it returns again.
+type SQLiteStore struct {
+	db     *sql.DB
+	config args.Args
+}
+
+// OpenSQLiteStore opens an existing store, Systems are built with config just like for the in-memory store.
+func OpenSQLiteStore(dbPath string, config args.Args) (*SQLiteStore, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: %w", dbPath, err)
+	}
+
+	// The records are stored as they were cached, a store of an older schema lacks data the evaluation relies on.
+	var version string
+	if err := db.QueryRow(`SELECT value FROM meta WHERE key = 'cache_schema_version'`).Scan(&version); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("%s: not a system store: %w", dbPath, err)
+	}
+	if version != fmt.Sprint(CacheSchemaVersion) {
+		db.Close()
+		return nil, fmt.Errorf("%s: store schema version %s, expected %d (run build-store again)", dbPath, version, CacheSchemaVersion)
+	}
+	return &SQLiteStore{db: db, config: config}, nil
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteStore) ForEachSystem(fn func(system EliteSystem) error) error {
+	rows, err := s.db.Query(`SELECT record FROM systems`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		system, err := s.scanSystem(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(system); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *SQLiteStore) SystemsAround(system EliteSystem, radius float32) ([]EliteSystem, error) {
+	// The R-tree narrows the search down to the bounding cube, the exact distance is checked afterwards.
+	rows, err := s.db.Query(`
+		SELECT systems.record FROM systems_rtree
+		JOIN systems ON systems.id64 = systems_rtree.id64
+		WHERE systems_rtree.min_x <= ? AND systems_rtree.max_x >= ?
+		  AND systems_rtree.min_y <= ? AND systems_rtree.max_y >= ?
+		  AND systems_rtree.min_z <= ? AND systems_rtree.max_z >= ?`,
+		system.X+radius, system.X-radius,
+		system.Y+radius, system.Y-radius,
+		system.Z+radius, system.Z-radius)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	candidates := make([]EliteSystem, 0)
+	for rows.Next() {
+		candidate, err := s.scanSystem(rows)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, candidate)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return appendSystems(system, radius, candidates, make([]EliteSystem, 0)), nil
+}
+
+func (s *SQLiteStore) FindSystem(name string) (EliteSystem, bool, error) {
+	rows, err := s.db.Query(`SELECT record FROM systems WHERE name = ? COLLATE NOCASE LIMIT 1`, name)
+	if err != nil {
+		return EliteSystem{}, false, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		return EliteSystem{}, false, rows.Err()
+	}
+	system, err := s.scanSystem(rows)
+	return system, err == nil, err
+}
+
+func (s *SQLiteStore) scanSystem(rows *sql.Rows) (EliteSystem, error) {
+	var record string
+	if err := rows.Scan(&record); err != nil {
+		return EliteSystem{}, err
+	}
+	var data EliteSystemJSON
+	if err := json.Unmarshal([]byte(record), &data); err != nil {
+		return EliteSystem{}, err
+	}
+	return buildSystem(data, s.config), nil
+}
@@ -0,0 +1,121 @@
+package dataBuilder
+
+import (
+	"encoding/json"
+	"fmt"
+	"massacre-finder/args"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// storeFixture returns a small bubble with target and giver factions, stations and rings.
+func storeFixture() []EliteSystemJSON {
+	random := rand.New(rand.NewSource(1))
+	randomCoordinate := func() float32 { return random.Float32()*60 - 30 }
+
+	systems := make([]EliteSystemJSON, 0, 300)
+	for i := 0; i < 300; i++ {
+		system := EliteSystemJSON{
+			Id:         uint64(1000 + i),
+			Name:       fmt.Sprintf("System %d", i),
+			Coords:     eliteSystemJSONCoords{X: randomCoordinate(), Y: randomCoordinate(), Z: randomCoordinate()},
+			Date:       "2023-01-01 00:00:00+00",
+			Population: int64(random.Intn(1000000)),
+			Government: "Democracy",
+			Factions: []eliteSystemJSONFaction{
+				{Name: "Givers " + fmt.Sprint(i%7), Government: "Democracy", Influence: 0.6},
+				{Name: "Pirates " + fmt.Sprint(i%5), Government: "Anarchy", Influence: 0.4},
+			},
+			Stations: []eliteSystemStationEntry{
+				{Name: fmt.Sprintf("Port %d", i), Type: "Coriolis Starport", DistanceToArrival: 100, Services: []string{"Missions"}},
+			},
+		}
+		if i%3 == 0 {
+			system.Bodies = []eliteSystemJSONBody{{
+				Name: system.Name + " 1", Type: "Planet", SubType: "Gas giant", DistanceToArrival: 500,
+				Rings: []eliteSystemJSONBodyRingEntry{{Name: system.Name + " 1 A Ring", Type: "Metallic"}},
+			}}
+		}
+		systems = append(systems, system)
+	}
+
+	// Round trip through JSON so the records look like decoded ones, e.g. with empty instead of nil state lists.
+	encoded, err := json.Marshal(systems)
+	if err != nil {
+		panic(err)
+	}
+	var decoded []EliteSystemJSON
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		panic(err)
+	}
+	return decoded
+}
+
+func TestSQLiteStoreMatchesMemoryStore(t *testing.T) {
+	config := args.Default()
+	data := storeFixture()
+	dbPath := filepath.Join(t.TempDir(), "systems.db")
+	if err := BuildSQLiteStore(dbPath, CacheHeader{SchemaVersion: CacheSchemaVersion}, data); err != nil {
+		t.Fatal(err)
+	}
+	sqliteStore, err := OpenSQLiteStore(dbPath, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqliteStore.Close()
+	memoryStore := NewMemoryStore(data, config)
+
+	for _, radius := range []float32{0, 5, 10, 25} {
+		err := memoryStore.ForEachSystem(func(system EliteSystem) error {
+			want, err := memoryStore.SystemsAround(system, radius)
+			if err != nil {
+				return err
+			}
+			got, err := sqliteStore.SystemsAround(system, radius)
+			if err != nil {
+				return err
+			}
+			sortById(want)
+			sortById(got)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("SystemsAround(%s, %g): the SQLite store found %d systems, the memory store %d", system.Name, radius, len(got), len(want))
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, name := range []string{"System 0", "system 42", "SYSTEM 299", "System 300"} {
+		want, wantFound, err := memoryStore.FindSystem(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, found, err := sqliteStore.FindSystem(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found != wantFound || !reflect.DeepEqual(got, want) {
+			t.Errorf("FindSystem(%q) = %+v, %v from the SQLite store, %+v, %v from the memory store", name, got, found, want, wantFound)
+		}
+	}
+}
+
+func TestOpenSQLiteStoreRejectsOtherSchemaVersions(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "systems.db")
+	if err := BuildSQLiteStore(dbPath, CacheHeader{SchemaVersion: CacheSchemaVersion - 1}, storeFixture()[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if store, err := OpenSQLiteStore(dbPath, args.Default()); err == nil {
+		store.Close()
+		t.Fatal("a store of an older schema version was opened")
+	}
+}
+
+func sortById(systems []EliteSystem) {
+	sort.Slice(systems, func(i, j int) bool { return systems[i].Id < systems[j].Id })
+}
@@ -0,0 +1,85 @@
+package dataBuilder
+
+import (
+	"massacre-finder/args"
+	"strings"
+)
+
+// SystemStore gives the evaluation access to the Systems and their neighbourhood, regardless of where they are held.
+type SystemStore interface {
+	// ForEachSystem calls fn for every System in the store and stops at the first error.
+	ForEachSystem(fn func(system EliteSystem) error) error
+	// SystemsAround returns all Systems within radius ly of system, not including system itself.
+	SystemsAround(system EliteSystem, radius float32) ([]EliteSystem, error)
+	// FindSystem looks a System up by name, ignoring case.
+	FindSystem(name string) (EliteSystem, bool, error)
+}
+
+// MemoryStore is the in-memory SystemStore, neighbours are looked up through a SpatialIndex.
+type MemoryStore struct {
+	systems []EliteSystem
+	index   SpatialIndex
+}
+
+func NewMemoryStore(systemsAsList []EliteSystemJSON, config args.Args) *MemoryStore {
+	systems := make([]EliteSystem, 0, len(systemsAsList))
+	for _, entry := range systemsAsList {
+		systems = append(systems, buildSystem(entry, config))
+	}
+	return &MemoryStore{systems: systems, index: NewKDTree(systems)}
+}
+
+func (s *MemoryStore) ForEachSystem(fn func(system EliteSystem) error) error {
+	for _, system := range s.systems {
+		if err := fn(system); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) FindSystem(name string) (EliteSystem, bool, error) {
+	for _, system := range s.systems {
+		if strings.EqualFold(system.Name, name) {
+			return system, true, nil
+		}
+	}
+	return EliteSystem{}, false, nil
+}
+
+func (s *MemoryStore) SystemsAround(system EliteSystem, radius float32) ([]EliteSystem, error) {
+	returnSystems := make([]EliteSystem, 0)
+	s.index.Within(system, radius, func(candidate EliteSystem) {
+		if candidate.Id != system.Id {
+			returnSystems = append(returnSystems, candidate)
+		}
+	})
+	return returnSystems, nil
+}
+
+func appendSystems(systemToIgnore EliteSystem, maxDistance float32, newSystems []EliteSystem, oldSystems []EliteSystem) []EliteSystem {
+	returnArray := oldSystems
+	maxDistanceSquared := maxDistance * maxDistance
+
+	for _, system := range newSystems {
+		if system.Id == systemToIgnore.Id {
+			continue
+		}
+		if DistanceSquared(system, systemToIgnore) > maxDistanceSquared {
+			continue
+		}
+
+		returnArray = append(returnArray, system)
+	}
+
+	return returnArray
+}
+
+// DistanceSquared returns the squared distance between two Systems in ly².
+func DistanceSquared(a EliteSystem, b EliteSystem) float32 {
+	x := a.X - b.X
+	y := a.Y - b.Y
+	z := a.Z - b.Z
+
+	return x*x + y*y + z*z
+}
@@ -0,0 +1,121 @@
+package dataBuilder
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// AppliedUpdate records a delta dump that has been applied to a cache.
+type AppliedUpdate struct {
+	Path      string
+	SHA256    string
+	AppliedAt time.Time
+	Latest    time.Time // newest record date in the dump
+}
+
+// UpdateResult summarises what applying one delta dump changed in the cache.
+type UpdateResult struct {
+	Path      string
+	Read      int
+	Added     int
+	Replaced  int
+	Removed   int
+	Unchanged int // records that are not newer than the cached System, or unpopulated Systems that are not cached
+	Skipped   int // malformed records
+}
+
+// UpdateCache applies spansh delta dumps (galaxy_1day.json, galaxy_7days.json, ...) in the given order to the cache
+// at cachePath. Systems are matched by id64 and only replaced by records with a newer date, so applying the same
+// or an older dump again changes nothing. Systems that are no longer populated are removed.
+func UpdateCache(cachePath string, deltas []Source) ([]UpdateResult, error) {
+	header, systems, err := readCache(cachePath)
+	if err != nil {
+		return nil, err
+	}
+
+	indexById := make(map[uint64]int, len(systems))
+	for i, system := range systems {
+		indexById[system.Id] = i
+	}
+	removed := make(map[int]bool)
+
+	results := make([]UpdateResult, 0, len(deltas))
+	for _, delta := range deltas {
+		result := UpdateResult{Path: delta.Path}
+		var latest time.Time
+		digest := sha256.New()
+
+		skipped, err := streamSystemData(delta, digest, func(record EliteSystemJSON) error {
+			result.Read++
+
+			updatedAt, hasDate := record.UpdatedAt()
+			if hasDate && updatedAt.After(latest) {
+				latest = updatedAt
+			}
+
+			index, cached := indexById[record.Id]
+			if cached && !isNewer(record, systems[index]) {
+				result.Unchanged++
+				return nil
+			}
+
+			switch {
+			case cached && !record.IsPopulated():
+				delete(indexById, record.Id)
+				removed[index] = true
+				result.Removed++
+			case cached:
+				systems[index] = record
+				result.Replaced++
+			case record.IsPopulated():
+				indexById[record.Id] = len(systems)
+				systems = append(systems, record)
+				result.Added++
+			default:
+				result.Unchanged++
+			}
+			return nil
+		})
+		if err != nil {
+			return results, err
+		}
+		result.Skipped = skipped
+		results = append(results, result)
+
+		header.Updates = append(header.Updates, AppliedUpdate{
+			Path:      delta.Path,
+			SHA256:    hex.EncodeToString(digest.Sum(nil)),
+			AppliedAt: time.Now().UTC(),
+			Latest:    latest,
+		})
+		if latest.After(header.LastUpdate) {
+			header.LastUpdate = latest
+		}
+	}
+
+	// Removed Systems stay in the slice until here so that the indices in indexById remain valid.
+	kept := make([]EliteSystemJSON, 0, len(systems)-len(removed))
+	for i, system := range systems {
+		if !removed[i] {
+			kept = append(kept, system)
+		}
+	}
+
+	if err := writeCache(cachePath, header, kept); err != nil {
+		return results, fmt.Errorf("%s: %w", cachePath, err)
+	}
+	return results, nil
+}
+
+// isNewer reports whether record is more recent than cached. Records without a date always win,
+// as there is no way to tell and the delta dump was passed in on purpose.
+func isNewer(record EliteSystemJSON, cached EliteSystemJSON) bool {
+	recordDate, recordHasDate := record.UpdatedAt()
+	cachedDate, cachedHasDate := cached.UpdatedAt()
+	if !recordHasDate || !cachedHasDate {
+		return true
+	}
+	return recordDate.After(cachedDate)
+}
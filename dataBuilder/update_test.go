@@ -0,0 +1,82 @@
+package dataBuilder
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const updateTestDump = `[
+	{"id64": 1, "name": "Alpha", "date": "2023-01-01 00:00:00+00", "population": 100, "factions": [{"name": "A"}]},
+	{"id64": 2, "name": "Beta", "date": "2023-01-01 00:00:00+00", "population": 200, "factions": [{"name": "B"}]}
+]`
+
+const updateTestDelta = `[
+	{"id64": 1, "name": "Alpha", "date": "2023-02-01 00:00:00+00", "population": 150, "factions": [{"name": "A"}]},
+	{"id64": 2, "name": "Beta", "date": "2023-02-01 00:00:00+00", "population": 0, "factions": []},
+	{"id64": 3, "name": "Gamma", "date": "2023-02-01 00:00:00+00", "population": 300, "factions": [{"name": "C"}]},
+	{"id64": 4, "name": "Delta", "date": "2023-02-01 00:00:00+00", "population": 0, "factions": []}
+]`
+
+func TestUpdateCacheIsIdempotent(t *testing.T) {
+	dir := t.TempDir()
+	cachePath := filepath.Join(dir, "system_cache.bin")
+	delta := Source{Path: writeTestFile(t, dir, "galaxy_1day.json", updateTestDelta)}
+
+	if _, err := buildCacheFile(cachePath, Source{Path: writeTestFile(t, dir, "galaxy_populated.json", updateTestDump)}); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := UpdateCache(cachePath, []Source{delta})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := UpdateResult{Path: delta.Path, Read: 4, Added: 1, Replaced: 1, Removed: 1, Unchanged: 1}
+	if len(results) != 1 || results[0] != want {
+		t.Fatalf("first update %+v, want %+v", results, want)
+	}
+	_, updated, err := readCache(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := systemIds(updated); !reflect.DeepEqual(ids, []uint64{1, 3}) {
+		t.Fatalf("cache holds systems %v after the update, want [1 3]", ids)
+	}
+
+	results, err = UpdateCache(cachePath, []Source{delta})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = UpdateResult{Path: delta.Path, Read: 4, Unchanged: 4}
+	if len(results) != 1 || results[0] != want {
+		t.Fatalf("second update %+v, want %+v", results, want)
+	}
+	header, again, err := readCache(cachePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(again, updated) {
+		t.Errorf("applying the delta again changed the systems from %+v to %+v", updated, again)
+	}
+	if len(header.Updates) != 2 {
+		t.Errorf("%d updates recorded, want 2", len(header.Updates))
+	}
+}
+
+func writeTestFile(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func systemIds(systems []EliteSystemJSON) []uint64 {
+	ids := make([]uint64, 0, len(systems))
+	for _, system := range systems {
+		ids = append(ids, system.Id)
+	}
+	return ids
+}
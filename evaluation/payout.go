@@ -0,0 +1,33 @@
+package evaluation
+
+import "massacre-finder/args"
+
+// PayoutEstimate is the income a stack at a target is expected to bring in.
+type PayoutEstimate struct {
+	RewardPerMission float64 `json:"rewardPerMission"`
+	CreditsPerStack  float64 `json:"creditsPerStack"`
+	HoursPerStack    float64 `json:"hoursPerStack"`
+	CreditsPerHour   float64 `json:"creditsPerHour"`
+}
+
+// estimatePayout prices the stack with the middle of the reward range for KillsPerMission, adjusted for reputation
+// and wing missions. The time is the one needed for the required kills at KillsPerHour.
+func estimatePayout(stack StackEstimate, config args.Args) PayoutEstimate {
+	bracket := config.RewardBracketFor(config.KillsPerMission)
+	reward := (bracket.MinReward + bracket.MaxReward) / 2
+	factor, _ := config.ReputationRewardFactors.Lookup(config.ReputationTier)
+	reward *= factor
+	if config.WingMissions {
+		reward *= config.WingRewardFactor
+	}
+
+	estimate := PayoutEstimate{
+		RewardPerMission: reward,
+		CreditsPerStack:  reward * stack.TotalMissions,
+		HoursPerStack:    stack.RequiredKills / config.KillsPerHour,
+	}
+	if estimate.HoursPerStack > 0 {
+		estimate.CreditsPerHour = estimate.CreditsPerStack / estimate.HoursPerStack
+	}
+	return estimate
+}
@@ -0,0 +1,56 @@
+package evaluation
+
+import "fmt"
+
+// RejectionReason names the rule a system failed to be a target.
+type RejectionReason string
+
+const (
+	RejectTargetFactionCount RejectionReason = "targetFactionCount"
+	RejectTargetState        RejectionReason = "targetState"
+	RejectNoSuitableRing     RejectionReason = "noSuitableRing"
+	RejectPopulation         RejectionReason = "population"
+	RejectAllegiance         RejectionReason = "allegiance"
+	RejectEconomy            RejectionReason = "economy"
+	RejectGovernment         RejectionReason = "government"
+	RejectPower              RejectionReason = "power"
+	RejectSourceSystems      RejectionReason = "sourceSystems"
+	RejectSourceStations     RejectionReason = "sourceStations"
+	RejectOutsideSystems     RejectionReason = "outsideSystems"
+	RejectOutsideTargets     RejectionReason = "outsideTargets"
+)
+
+var rejectionRules = map[RejectionReason]string{
+	RejectTargetFactionCount: "the system must have exactly one target faction, or at least one with MultiTargetFactions",
+	RejectTargetState:        "the target faction must not be in an excluded state (ExcludeTargetStates)",
+	RejectNoSuitableRing:     "the system must have a ring suitable for RES (FilterOnlyRingedSource, MinRingSuitability)",
+	RejectPopulation:         "the population must be within MinTargetPopulation and MaxTargetPopulation",
+	RejectAllegiance:         "the allegiance must be one of TargetAllegiances",
+	RejectEconomy:            "the primary economy must be one of TargetEconomies",
+	RejectGovernment:         "the system government must be one of TargetSystemGovernments",
+	RejectPower:              "the system must not be controlled or exploited by one of ExcludeTargetPowers",
+	RejectSourceSystems:      "there must be enough populated systems in range (MinSourceSystemCount)",
+	RejectSourceStations:     "the source systems must have enough eligible stations (MinSourceStationCount)",
+	RejectOutsideSystems:     "the source systems must not reach too many other destinations (MaxOtherDestSystemsForSource)",
+	RejectOutsideTargets:     "those other destinations must not have too many target factions (MaxOtherDestSystemsForSourceAnarchyCount)",
+}
+
+// Rejection explains why a system is not a target: the failed rule and the observed value versus the threshold.
+type Rejection struct {
+	Reason    RejectionReason `json:"reason"`
+	Observed  string          `json:"observed"`
+	Threshold string          `json:"threshold"`
+}
+
+func reject(reason RejectionReason, observed interface{}, threshold interface{}) *Rejection {
+	return &Rejection{Reason: reason, Observed: fmt.Sprint(observed), Threshold: fmt.Sprint(threshold)}
+}
+
+// Rule describes the failed rule in words.
+func (r Rejection) Rule() string {
+	return rejectionRules[r.Reason]
+}
+
+func (r Rejection) String() string {
+	return fmt.Sprintf("%s: observed %s, threshold %s", r.Reason, r.Observed, r.Threshold)
+}
@@ -0,0 +1,42 @@
+package evaluation
+
+import (
+	"massacre-finder/args"
+	"massacre-finder/dataBuilder"
+)
+
+// RingSuitability rates how well a ring is suited for Resource Extraction Sites, 0 meaning not at all.
+// Belts never have RES, ring types missing from the configuration count as 0.
+func RingSuitability(ring dataBuilder.EliteRing, config args.Args) float64 {
+	if ring.IsBelt {
+		return 0
+	}
+	suitability, _ := config.RingTypeSuitability.Lookup(ring.Type)
+	if ring.IsGasGiant() {
+		suitability *= config.GasGiantRingFactor
+	}
+	return suitability
+}
+
+// nearestSuitableRing returns the suitable ring closest to arrival, preferring the more suitable one on equal distance.
+func nearestSuitableRing(system dataBuilder.EliteSystem, config args.Args) (dataBuilder.EliteRing, float64, bool) {
+	var nearest dataBuilder.EliteRing
+	best := 0.0
+	found := false
+	for _, ring := range system.Rings {
+		suitability := RingSuitability(ring, config)
+		if suitability < config.MinRingSuitability || suitability == 0 {
+			continue
+		}
+		if !found || ring.DistanceToArrival < nearest.DistanceToArrival ||
+			(ring.DistanceToArrival == nearest.DistanceToArrival && suitability > best) {
+			nearest, best, found = ring, suitability, true
+		}
+	}
+	return nearest, best, found
+}
+
+// ringScore is the bonus for the nearest suitable ring, halved at RingDistanceFalloffLs from arrival.
+func ringScore(ring dataBuilder.EliteRing, suitability float64, config args.Args) float32 {
+	return float32(config.RingBonusWeight * suitability / (1 + float64(ring.DistanceToArrival)/config.RingDistanceFalloffLs))
+}
@@ -0,0 +1,192 @@
+package evaluation
+
+import (
+	"fmt"
+	"massacre-finder/args"
+	"massacre-finder/dataBuilder"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Metrics are the facts about a target system that scoring formulas are based on.
+type Metrics struct {
+	NearestSuitableRing *dataBuilder.EliteRing // nil if the system has no suitable ring
+	RingSuitability     float64
+	// GiverFactionBoards maps every giver faction to the number of source systems with an eligible station it is in,
+	// or to the number of eligible stations in those systems with WeightGiversByStations.
+	GiverFactionBoards        map[string]int
+	InsideTargetFactionCount  int // target factions in the source systems
+	OutsideSystemCount        int // other destination systems reachable from the source systems
+	OutsideTargetFactionCount int // target factions in those systems
+	// SiblingTargetWeight sums the influence of the other target factions in the target system relative to the target.
+	SiblingTargetWeight float64
+	// The attributes of the target system, scored by EconomyBonus, GovernmentBonus, PowerBonus and PopulationWeight.
+	Population       int64
+	PrimaryEconomy   string
+	Government       string // of the system, not of the target faction
+	ControllingPower string
+}
+
+// A Scorer turns the Metrics of a target into a score, higher is better.
+type Scorer interface {
+	Score(metrics Metrics, config args.Args) ScoreBreakdown
+}
+
+// ScoreBreakdown lists the terms a score is made of. Penalties are positive and subtracted.
+type ScoreBreakdown struct {
+	RingBonus            float32               `json:"ringBonus"`
+	FactionBonus         float32               `json:"factionBonus"` // sum of Factions
+	Factions             []FactionContribution `json:"factions,omitempty"`
+	InsideTargetPenalty  float32               `json:"insideTargetPenalty"`
+	OutsideTargetPenalty float32               `json:"outsideTargetPenalty"`
+	OutsideSystemPenalty float32               `json:"outsideSystemPenalty"`
+	SiblingTargetPenalty float32               `json:"siblingTargetPenalty,omitempty"`
+	SystemBonus          float32               `json:"systemBonus,omitempty"` // economy, government, power and population of the target system
+	Adjustment           float32               `json:"adjustment,omitempty"`  // formula specific, e.g. the scaling of the ratio scorer
+}
+
+// FactionContribution is the part of the score a single source faction is responsible for.
+type FactionContribution struct {
+	Name    string  `json:"name"`
+	Systems int     `json:"systems"` // source systems with eligible stations, or stations with WeightGiversByStations
+	Score   float32 `json:"score"`
+}
+
+// Total is the score the breakdown adds up to.
+func (b ScoreBreakdown) Total() float32 {
+	return b.RingBonus + b.FactionBonus + b.SystemBonus + b.Adjustment - b.InsideTargetPenalty - b.OutsideTargetPenalty - b.OutsideSystemPenalty - b.SiblingTargetPenalty
+}
+
+// String renders the breakdown on one line, e.g. for the console.
+func (b ScoreBreakdown) String() string {
+	text := fmt.Sprintf("ring %+.2f, %d factions %+.2f, inside targets -%.2f, outside targets -%.2f, outside systems -%.2f",
+		b.RingBonus, len(b.Factions), b.FactionBonus, b.InsideTargetPenalty, b.OutsideTargetPenalty, b.OutsideSystemPenalty)
+	if b.SiblingTargetPenalty != 0 {
+		text += fmt.Sprintf(", sibling targets -%.2f", b.SiblingTargetPenalty)
+	}
+	if b.SystemBonus != 0 {
+		text += fmt.Sprintf(", system %+.2f", b.SystemBonus)
+	}
+	if b.Adjustment != 0 {
+		text += fmt.Sprintf(", adjustment %+.2f", b.Adjustment)
+	}
+	return text
+}
+
+var scorers = map[string]Scorer{
+	args.ScorerDefault:  defaultScorer{},
+	args.ScorerFactions: factionsScorer{},
+	args.ScorerRatio:    ratioScorer{},
+}
+
+// ScorerByName returns the Scorer registered under name (see args.KnownScorers), ignoring case.
+func ScorerByName(name string) (Scorer, error) {
+	for scorerName, scorer := range scorers {
+		if strings.EqualFold(scorerName, name) {
+			return scorer, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown scorer \"%s\"", name)
+}
+
+// defaultScorer is the original formula: the ring bonus plus 2 - 1/count for every source faction, minus the
+// squared outside target faction count, the outside system count and the inside target faction count. Every term is weighted
+// by the configuration, the defaults give the plain formula.
+type defaultScorer struct{}
+
+func (defaultScorer) Score(metrics Metrics, config args.Args) ScoreBreakdown {
+	breakdown := factionBreakdown(metrics, config)
+	breakdown.RingBonus = metricsRingScore(metrics, config)
+	breakdown.OutsideTargetPenalty = float32(config.OutsideTargetPenalty) * float32(metrics.OutsideTargetFactionCount*metrics.OutsideTargetFactionCount)
+	breakdown.OutsideSystemPenalty = float32(config.OutsideSystemPenalty) * float32(metrics.OutsideSystemCount)
+	breakdown.InsideTargetPenalty = float32(config.InsideTargetPenalty) * float32(metrics.InsideTargetFactionCount)
+	breakdown.SiblingTargetPenalty = siblingPenalty(metrics, config)
+	breakdown.SystemBonus = systemBonus(metrics, config)
+	return breakdown
+}
+
+// factionsScorer only counts mission givers: one point per source faction, minus one per competing target faction.
+// Rings are ignored, for players who do not need RES to find the targets.
+type factionsScorer struct{}
+
+func (factionsScorer) Score(metrics Metrics, config args.Args) ScoreBreakdown {
+	breakdown := ScoreBreakdown{
+		InsideTargetPenalty:  float32(metrics.InsideTargetFactionCount),
+		OutsideTargetPenalty: float32(metrics.OutsideTargetFactionCount),
+		SiblingTargetPenalty: siblingPenalty(metrics, config),
+		SystemBonus:          systemBonus(metrics, config),
+	}
+	for name, count := range metrics.GiverFactionBoards {
+		breakdown.Factions = append(breakdown.Factions, FactionContribution{Name: name, Systems: count, Score: 1})
+		breakdown.FactionBonus++
+	}
+	sortContributions(breakdown.Factions)
+	return breakdown
+}
+
+// ratioScorer rates the share of the missions that end up at the target: the default faction contribution
+// divided by the number of target factions competing for them, plus the ring bonus.
+type ratioScorer struct{}
+
+func (ratioScorer) Score(metrics Metrics, config args.Args) ScoreBreakdown {
+	breakdown := factionBreakdown(metrics, config)
+	breakdown.RingBonus = metricsRingScore(metrics, config)
+	// The competing target factions scale the faction part down instead of being subtracted.
+	share := breakdown.FactionBonus / float32(1+metrics.InsideTargetFactionCount+metrics.OutsideTargetFactionCount)
+	breakdown.Adjustment = share - breakdown.FactionBonus
+	breakdown.SiblingTargetPenalty = siblingPenalty(metrics, config)
+	breakdown.SystemBonus = systemBonus(metrics, config)
+	return breakdown
+}
+
+// factionBreakdown scores every source faction with FactionBaseScore - FactionCountDecay/count.
+func factionBreakdown(metrics Metrics, config args.Args) ScoreBreakdown {
+	var breakdown ScoreBreakdown
+	for name, count := range metrics.GiverFactionBoards {
+		score := float32(config.FactionBaseScore) - float32(config.FactionCountDecay)/float32(count)
+		breakdown.Factions = append(breakdown.Factions, FactionContribution{Name: name, Systems: count, Score: score})
+		breakdown.FactionBonus += score
+	}
+	sortContributions(breakdown.Factions)
+	return breakdown
+}
+
+// sortContributions orders the biggest contributions first, ties by name to keep results reproducible.
+func sortContributions(factions []FactionContribution) {
+	sort.Slice(factions, func(i, j int) bool {
+		if factions[i].Score != factions[j].Score {
+			return factions[i].Score > factions[j].Score
+		}
+		return factions[i].Name < factions[j].Name
+	})
+}
+
+func siblingPenalty(metrics Metrics, config args.Args) float32 {
+	return float32(config.SiblingTargetPenalty * metrics.SiblingTargetWeight)
+}
+
+// systemBonus scores the attributes of the target system, all of the weights are zero by default.
+func systemBonus(metrics Metrics, config args.Args) float32 {
+	bonus := 0.0
+	if weight, found := config.EconomyBonus.Lookup(metrics.PrimaryEconomy); found {
+		bonus += weight
+	}
+	if weight, found := config.GovernmentBonus.Lookup(metrics.Government); found {
+		bonus += weight
+	}
+	if weight, found := config.PowerBonus.Lookup(metrics.ControllingPower); found {
+		bonus += weight
+	}
+	if metrics.Population > 0 {
+		bonus += config.PopulationWeight * math.Log10(float64(metrics.Population))
+	}
+	return float32(bonus)
+}
+
+func metricsRingScore(metrics Metrics, config args.Args) float32 {
+	if metrics.NearestSuitableRing == nil {
+		return 0
+	}
+	return ringScore(*metrics.NearestSuitableRing, metrics.RingSuitability, config)
+}
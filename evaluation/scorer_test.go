@@ -0,0 +1,35 @@
+package evaluation
+
+import (
+	"massacre-finder/args"
+	"math"
+	"testing"
+)
+
+func TestSystemBonus(t *testing.T) {
+	config := args.Default()
+	config.EconomyBonus = args.WeightMap{"Extraction": 0.5}
+	config.GovernmentBonus = args.WeightMap{"anarchy": 1}
+	config.PowerBonus = args.WeightMap{"Zachary Hudson": -2}
+	config.PopulationWeight = -0.5
+
+	metrics := Metrics{Population: 1000000, PrimaryEconomy: "Extraction", Government: "Anarchy", ControllingPower: "Zachary Hudson"}
+	want := float32(0.5 + 1 - 2 - 0.5*6)
+	for _, name := range args.KnownScorers {
+		scorer, err := ScorerByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		breakdown := scorer.Score(metrics, config)
+		if math.Abs(float64(breakdown.SystemBonus-want)) > 1e-6 {
+			t.Errorf("%s: system bonus %g, want %g", name, breakdown.SystemBonus, want)
+		}
+	}
+
+	if bonus := systemBonus(Metrics{PrimaryEconomy: "Tourism"}, config); bonus != 0 {
+		t.Errorf("unlisted system scores %g, want 0", bonus)
+	}
+	if bonus := systemBonus(metrics, args.Default()); bonus != 0 {
+		t.Errorf("default weights score %g, want 0", bonus)
+	}
+}
@@ -0,0 +1,65 @@
+package evaluation
+
+import (
+	"math"
+	"sort"
+)
+
+// StackEstimate is what a stack at a target is expected to look like if the player takes as many missions as the
+// mission cap allows and spreads them evenly across the giver factions.
+type StackEstimate struct {
+	MissionsPerFaction map[string]float64 `json:"missionsPerFaction"` // missions taken per giver faction
+	TotalMissions      float64            `json:"totalMissions"`      // at most MissionCap
+	RequiredKills      float64            `json:"requiredKills"`      // kills for the faction with the most missions, the others complete alongside
+	StackingRatio      float64            `json:"stackingRatio"`      // kills paid for per kill made
+	// TargetShare is the part of the missions of every giver that is against the target, below 1 if other target
+	// factions in the system take their share (MultiTargetFactions).
+	TargetShare float64 `json:"targetShare"`
+}
+
+// estimateStack derives the stack from the mission boards of every giver faction. Missions of one faction are
+// completed one after another while those of different factions count the same kills, so the missions under the cap
+// are distributed by water filling: every faction gets the same number unless it offers fewer.
+func estimateStack(giverFactionBoards map[string]int, missionsPerBoard float64, killsPerMission float64, missionCap int) StackEstimate {
+	estimate := StackEstimate{MissionsPerFaction: make(map[string]float64, len(giverFactionBoards))}
+	if len(giverFactionBoards) == 0 {
+		return estimate
+	}
+
+	offered := make([]float64, 0, len(giverFactionBoards))
+	total := 0.0
+	for _, boards := range giverFactionBoards {
+		missions := float64(boards) * missionsPerBoard
+		offered = append(offered, missions)
+		total += missions
+	}
+
+	// level is the most missions taken from a single faction.
+	level := math.Inf(1)
+	if limit := float64(missionCap); total > limit {
+		sort.Float64s(offered)
+		remaining := limit
+		for i, missions := range offered {
+			share := remaining / float64(len(offered)-i)
+			if missions >= share {
+				level = share
+				break
+			}
+			remaining -= missions
+		}
+	}
+
+	maxMissions := 0.0
+	for name, boards := range giverFactionBoards {
+		missions := math.Min(float64(boards)*missionsPerBoard, level)
+		estimate.MissionsPerFaction[name] = missions
+		estimate.TotalMissions += missions
+		maxMissions = math.Max(maxMissions, missions)
+	}
+
+	estimate.RequiredKills = maxMissions * killsPerMission
+	if maxMissions > 0 {
+		estimate.StackingRatio = estimate.TotalMissions / maxMissions
+	}
+	return estimate
+}
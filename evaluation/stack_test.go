@@ -0,0 +1,103 @@
+package evaluation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestEstimateStack(t *testing.T) {
+	tests := []struct {
+		name             string
+		boards           map[string]int
+		missionsPerBoard float64
+		missionCap       int
+		want             map[string]float64
+		wantKills        float64
+		wantRatio        float64
+	}{
+		{
+			name:       "no givers",
+			boards:     map[string]int{},
+			missionCap: 20,
+			want:       map[string]float64{},
+		},
+		{
+			name:             "below the cap everything is taken",
+			boards:           map[string]int{"A": 1, "B": 2},
+			missionsPerBoard: 3,
+			missionCap:       20,
+			want:             map[string]float64{"A": 3, "B": 6},
+			wantKills:        36,
+			wantRatio:        1.5,
+		},
+		{
+			name:             "the cap is split evenly",
+			boards:           map[string]int{"A": 5, "B": 5, "C": 5, "D": 5},
+			missionsPerBoard: 2,
+			missionCap:       20,
+			want:             map[string]float64{"A": 5, "B": 5, "C": 5, "D": 5},
+			wantKills:        30,
+			wantRatio:        4,
+		},
+		{
+			name:             "small factions leave their share to the others",
+			boards:           map[string]int{"A": 1, "B": 2, "C": 10},
+			missionsPerBoard: 2,
+			missionCap:       20,
+			want:             map[string]float64{"A": 2, "B": 4, "C": 14},
+			wantKills:        84,
+			wantRatio:        20.0 / 14,
+		},
+		{
+			name:             "exactly at the cap",
+			boards:           map[string]int{"A": 2, "B": 3},
+			missionsPerBoard: 4,
+			missionCap:       20,
+			want:             map[string]float64{"A": 8, "B": 12},
+			wantKills:        72,
+			wantRatio:        20.0 / 12,
+		},
+		{
+			name:             "a faction with no missions",
+			boards:           map[string]int{"A": 0, "B": 4},
+			missionsPerBoard: 2,
+			missionCap:       5,
+			want:             map[string]float64{"A": 0, "B": 5},
+			wantKills:        30,
+			wantRatio:        1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			estimate := estimateStack(test.boards, test.missionsPerBoard, 6, test.missionCap)
+
+			if len(estimate.MissionsPerFaction) != len(test.want) {
+				t.Fatalf("missions per faction %v, want %v", estimate.MissionsPerFaction, test.want)
+			}
+			total := 0.0
+			for name, want := range test.want {
+				if got := estimate.MissionsPerFaction[name]; !approximately(got, want) {
+					t.Errorf("%s takes %g missions, want %g", name, got, want)
+				}
+				total += want
+			}
+			if !approximately(estimate.TotalMissions, total) {
+				t.Errorf("total missions %g, want %g", estimate.TotalMissions, total)
+			}
+			if estimate.TotalMissions > float64(test.missionCap)+1e-9 {
+				t.Errorf("total missions %g exceed the cap of %d", estimate.TotalMissions, test.missionCap)
+			}
+			if !approximately(estimate.RequiredKills, test.wantKills) {
+				t.Errorf("required kills %g, want %g", estimate.RequiredKills, test.wantKills)
+			}
+			if !approximately(estimate.StackingRatio, test.wantRatio) {
+				t.Errorf("stacking ratio %g, want %g", estimate.StackingRatio, test.wantRatio)
+			}
+		})
+	}
+}
+
+func approximately(a float64, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
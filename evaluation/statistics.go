@@ -0,0 +1,44 @@
+package evaluation
+
+import (
+	"massacre-finder/dataBuilder"
+	"sort"
+)
+
+// RejectionStatistics sums up a run: how many systems each rule ruled out and how many stations buildSystem
+// dropped. The rule with the most rejections is usually the threshold to look at when a run finds nothing.
+type RejectionStatistics struct {
+	Evaluated        int                           `json:"evaluated"`
+	Targets          int                           `json:"targets"` // one per (system, target faction)
+	Rejected         map[RejectionReason]int       `json:"rejected"`
+	RejectedStations dataBuilder.StationRejections `json:"rejectedStations"`
+}
+
+func NewRejectionStatistics() RejectionStatistics {
+	return RejectionStatistics{Rejected: make(map[RejectionReason]int)}
+}
+
+// Add counts the evaluation of system that found targets results, rejection is nil unless there are none.
+func (s *RejectionStatistics) Add(system dataBuilder.EliteSystem, targets int, rejection *Rejection) {
+	s.Evaluated++
+	s.Targets += targets
+	s.RejectedStations.Add(system.RejectedStations)
+	if rejection != nil {
+		s.Rejected[rejection.Reason]++
+	}
+}
+
+// ReasonsByCount returns the reasons that rejected systems, the most frequent first.
+func (s RejectionStatistics) ReasonsByCount() []RejectionReason {
+	reasons := make([]RejectionReason, 0, len(s.Rejected))
+	for reason := range s.Rejected {
+		reasons = append(reasons, reason)
+	}
+	sort.Slice(reasons, func(i, j int) bool {
+		if s.Rejected[reasons[i]] != s.Rejected[reasons[j]] {
+			return s.Rejected[reasons[i]] > s.Rejected[reasons[j]]
+		}
+		return reasons[i] < reasons[j]
+	})
+	return reasons
+}
@@ -1,222 +1,325 @@
 package evaluation
 
-import "massacre-finder/dataBuilder"
-import "massacre-finder/args"
+import (
+	"fmt"
+	"massacre-finder/args"
+	"massacre-finder/dataBuilder"
+	"strings"
+)
 
 type SystemEvaluationResult struct {
-	Score                          float32                   `json:"score,omitempty"`
-	AnarchyFactionName             string                    `json:"anarchyFactionName,omitempty"`
-	SystemName                     string                    `json:"systemName,omitempty"`
-	SourceSystemAnarchyFactionCount int 					 `json:"sourceSystemAnarchyFactionCount"`
-	SourcingFactionsCount          int                       `json:"sourcingFactionsCount,omitempty"`
-	SourcingSystems                int                       `json:"sourcingSystems,omitempty"`
-	ExternalSystemCount            int                       `json:"externalSystemCount,omitempty"`
-	ExternalSystemCountWithAnarchy int                       `json:"externalSystemCountWithAnarchy,omitempty"`
-	Rings                          int                       `json:"rings,omitempty"`
-	MetaSurroundingSystems         []dataBuilder.EliteSystem `json:"metaSurroundingSystems,omitempty"`
-	MetaSystem                     dataBuilder.EliteSystem   `json:"metaSystem"`
+	Score                            float32                   `json:"score,omitempty"`
+	ScoreBreakdown                   ScoreBreakdown            `json:"scoreBreakdown"`
+	TargetFactionName                string                    `json:"targetFactionName,omitempty"`
+	SiblingTargetFactions            []string                  `json:"siblingTargetFactions,omitempty"` // other target factions in the system
+	SystemName                       string                    `json:"systemName,omitempty"`
+	SourceSystemTargetFactionCount   int                       `json:"sourceSystemTargetFactionCount"`
+	GiverFactionsCount               int                       `json:"giverFactionsCount,omitempty"`
+	SourcingSystems                  int                       `json:"sourcingSystems,omitempty"`
+	ExternalSystemCount              int                       `json:"externalSystemCount,omitempty"`
+	ExternalSystemTargetFactionCount int                       `json:"externalSystemTargetFactionCount,omitempty"`
+	Stack                            StackEstimate             `json:"stack"`
+	Payout                           PayoutEstimate            `json:"payout"`
+	Rings                            int                       `json:"rings,omitempty"`
+	NearestSuitableRing              *dataBuilder.EliteRing    `json:"nearestSuitableRing,omitempty"`
+	RingSuitability                  float64                   `json:"ringSuitability,omitempty"`
+	Population                       int64                     `json:"population,omitempty"`
+	SourcePopulation                 int64                     `json:"sourcePopulation,omitempty"`
+	ControllingFaction               string                    `json:"controllingFaction,omitempty"`
+	PrimaryEconomy                   string                    `json:"primaryEconomy,omitempty"`
+	Allegiance                       string                    `json:"allegiance,omitempty"`
+	Government                       string                    `json:"government,omitempty"`
+	ControllingPower                 string                    `json:"controllingPower,omitempty"`
+	PowerState                       string                    `json:"powerState,omitempty"`
+	MetaSurroundingSystems           []dataBuilder.EliteSystem `json:"metaSurroundingSystems,omitempty"`
+	MetaSystem                       dataBuilder.EliteSystem   `json:"metaSystem"`
 }
 
-// EvaluateSystem evaluates the current Systems "goodness" for being a Stacking System.
-func EvaluateSystem(system dataBuilder.EliteSystem, dataStore map[dataBuilder.EliteSector][]dataBuilder.EliteSystem, config args.Args) (SystemEvaluationResult, bool) {
+// EvaluateSystem evaluates the current Systems "goodness" for being a Stacking System. Usually the system needs
+// exactly one target faction, with MultiTargetFactions every target faction is evaluated as its own target and
+// each one that passes gives a result. Systems without a target come back with the Rejection that ruled them out,
+// the one of the first faction if several were rejected.
+// Neighbour lookups that fail (e.g. in an on-disk store) are returned as error.
+func EvaluateSystem(system dataBuilder.EliteSystem, dataStore dataBuilder.SystemStore, config args.Args) ([]SystemEvaluationResult, *Rejection, error) {
 
 	// Do a check to see if this System is a good dest. candidate.
-	if system.AnarchyFactionCount != 1 {
-		return SystemEvaluationResult{}, false // Not a valid candidate
+	if config.MultiTargetFactions && system.TargetFactionCount == 0 {
+		return nil, reject(RejectTargetFactionCount, system.TargetFactionCount, ">= 1"), nil
 	}
-
-	if config.FilterOnlyRingedSource && system.RingQty == 0 {
-		return SystemEvaluationResult{}, false // No Rings
+	if !config.MultiTargetFactions && system.TargetFactionCount != 1 {
+		return nil, reject(RejectTargetFactionCount, system.TargetFactionCount, 1), nil
 	}
 
-	score := float32(0)
+	results := make([]SystemEvaluationResult, 0, len(system.TargetFactionNames))
+	var firstRejection *Rejection
+	around := &neighbourhood{}
+	for _, targetName := range system.TargetFactionNames {
+		result, rejection, err := evaluateTarget(system, targetName, around, dataStore, config)
+		if err != nil {
+			return nil, nil, err
+		}
+		if rejection != nil {
+			if firstRejection == nil {
+				firstRejection = rejection
+			}
+			continue
+		}
+		results = append(results, result)
+	}
 
-	if system.RingQty > 0 {
-		score += 2 - 1.0/float32(system.RingQty)
+	if len(results) == 0 {
+		return nil, firstRejection, nil
 	}
+	return results, nil, nil
+}
 
-	// Contains all Systems around the target system within a 10ly radius
-	populatedSystemsInRange := getAllPopulatedSystemsIn10LyRadius(system, dataStore)
+// neighbourhood holds what the evaluation needs to know about the systems around a target system. It is the same for
+// every target faction of the system, so it is only looked up for the first one that gets that far. The systems
+// around the sources are a second step, as most candidates are already rejected for their sources.
+type neighbourhood struct {
+	sourcesLoaded      bool
+	outsideLoaded      bool
+	sources            []dataBuilder.EliteSystem // populated systems within the mission radius
+	stationCount       int                       // eligible stations in the sources
+	sourceTargetCount  int                       // target factions in the sources
+	sourcePopulation   int64
+	outsideSystemCount int // other destination systems reachable from the sources
+	outsideTargetCount int // target factions in those systems
+	giverFactionBoards map[string]int
+}
 
-	if len(populatedSystemsInRange) < config.MinSourceSystemCount {
-		return SystemEvaluationResult{}, false
+// loadSources looks the source systems of system up in dataStore, unless that has been done already.
+func (n *neighbourhood) loadSources(system dataBuilder.EliteSystem, dataStore dataBuilder.SystemStore, config args.Args) error {
+	if n.sourcesLoaded {
+		return nil
 	}
+	radius := float32(config.MissionRadiusLy)
 
-
+	// Contains all Systems around the target system within the mission radius
+	populatedSystemsInRange, err := dataStore.SystemsAround(system, radius)
+	if err != nil {
+		return err
+	}
 
 	stationCount := 0
-	sourceSystemAnarchyCount := 0
+	sourceSystemTargetCount := 0
+	sourcePopulation := int64(0)
 	for _, sys := range populatedSystemsInRange {
-		sourceSystemAnarchyCount += int(sys.AnarchyFactionCount)
+		sourceSystemTargetCount += int(sys.TargetFactionCount)
+		sourcePopulation += sys.Population
 		for _, station := range sys.Stations {
-			if  station.Distance < float32(config.MaxDistanceInLsForStationToBeConsidered) {
+			if station.Distance < float32(config.MaxDistanceInLsForStationToBeConsidered) {
 				stationCount++
 			}
 		}
 	}
 
-	if stationCount < config.MinSourceStationCount {
-		return SystemEvaluationResult{}, false
+	// inverse mapping of faction count and qty to score. A faction only hands out missions where there is an
+	// eligible station, so systems without one do not count.
+	giverFactionQtyMapping := make(map[string]int)
+	for _, s := range populatedSystemsInRange {
+		if len(s.Stations) == 0 {
+			continue
+		}
+		boards := 1
+		if config.WeightGiversByStations {
+			boards = len(s.Stations)
+		}
+
+		for _, f := range s.GiverFactionNames {
+			giverFactionQtyMapping[f] += boards
+		}
+	}
+
+	n.sourcesLoaded = true
+	n.sources = populatedSystemsInRange
+	n.stationCount = stationCount
+	n.sourceTargetCount = sourceSystemTargetCount
+	n.sourcePopulation = sourcePopulation
+	n.giverFactionBoards = giverFactionQtyMapping
+	return nil
+}
+
+// loadOutside counts the other destinations reachable from the sources, unless that has been done already.
+func (n *neighbourhood) loadOutside(system dataBuilder.EliteSystem, dataStore dataBuilder.SystemStore, config args.Args) error {
+	if n.outsideLoaded {
+		return nil
 	}
+	radius := float32(config.MissionRadiusLy)
 
 	var systemToSurroundingSystemsLookup = make(map[uint64]dataBuilder.EliteSystem)
 
 	// Go through all Systems that are accessible by the Source systems
-	for _, newSystem := range populatedSystemsInRange {
-		systemsOfGivenSystemInRange := getAllPopulatedSystemsIn10LyRadius(newSystem, dataStore)
+	for _, newSystem := range n.sources {
+		systemsOfGivenSystemInRange, err := dataStore.SystemsAround(newSystem, radius)
+		if err != nil {
+			return err
+		}
 		for _, s := range systemsOfGivenSystemInRange {
-			if sysDistanceSquared(s, system) > 10*10 {
+			if dataBuilder.DistanceSquared(s, system) > radius*radius {
 				systemToSurroundingSystemsLookup[s.Id] = s
 			}
 		}
 	}
-	// Find all the Systems that are destination systems but not source systems -> > 10ly from current system
+	// Find all the Systems that are destination systems but not source systems -> outside the radius of the current system
 	outsideSystemCount := 0
-	outsideSystemAnarchyCount := 0
-
-	//////////////////// Negative Calculations ///////////////////////////
-
+	outsideSystemTargetCount := 0
 	for _, outsideSystem := range systemToSurroundingSystemsLookup {
 		outsideSystemCount++
-		outsideSystemAnarchyCount += int(outsideSystem.AnarchyFactionCount)
+		outsideSystemTargetCount += int(outsideSystem.TargetFactionCount)
 	}
 
-	if outsideSystemCount > config.MaxOtherDestSystemsForSource {
-		return SystemEvaluationResult{}, false
-	}
+	n.outsideLoaded = true
+	n.outsideSystemCount = outsideSystemCount
+	n.outsideTargetCount = outsideSystemTargetCount
+	return nil
+}
 
-	if outsideSystemAnarchyCount > config.MaxOtherDestSystemsForSourceAnarchyCount {
-		return SystemEvaluationResult{}, false
+// evaluateTarget evaluates system with the target faction targetName as the target of the missions.
+func evaluateTarget(system dataBuilder.EliteSystem, targetName string, around *neighbourhood, dataStore dataBuilder.SystemStore, config args.Args) (SystemEvaluationResult, *Rejection, error) {
+	targetFaction, hasTargetFaction := system.FindFaction(targetName)
+	if hasTargetFaction && targetFaction.HasActiveState(config.ExcludeTargetStates) {
+		// Target faction is e.g. in Retreat
+		return SystemEvaluationResult{}, reject(RejectTargetState, strings.Join(targetFaction.ActiveStates, ", "), "none of "+strings.Join(config.ExcludeTargetStates, ", ")), nil
 	}
 
-	score -= float32(outsideSystemAnarchyCount*outsideSystemAnarchyCount) + float32(outsideSystemCount)
-
-	// Find the "inside" anarchy count
-	insideSystemAnarchyCount := 0
-	for _, s := range populatedSystemsInRange {
-		insideSystemAnarchyCount += int(s.AnarchyFactionCount)
+	ring, suitability, hasRing := nearestSuitableRing(system, config)
+	if config.FilterOnlyRingedSource && !hasRing {
+		return SystemEvaluationResult{}, reject(RejectNoSuitableRing, fmt.Sprintf("%d ringed bodies, none suitable", system.RingQty), fmt.Sprintf("suitability >= %g", config.MinRingSuitability)), nil
 	}
 
-	score -= float32(insideSystemAnarchyCount)
-
-	//////////////////// Positive Calculations ///////////////////////////
-	// inverse mapping of faction count and qty to score
-	nonAnarchyFactionQtyMapping := make(map[string]int)
-	for _, s := range populatedSystemsInRange {
+	if rejection := matchesSystemFilters(system, config); rejection != nil {
+		return SystemEvaluationResult{}, rejection, nil // Population, allegiance, economy, government or powerplay
+	}
 
-		for _, f := range s.NonAnarchyFactionNames {
-			_, exists := nonAnarchyFactionQtyMapping[f]
-			if !exists {
-				nonAnarchyFactionQtyMapping[f] = 0
-			}
+	scorer, err := ScorerByName(config.Scorer)
+	if err != nil {
+		return SystemEvaluationResult{}, nil, err
+	}
 
-			nonAnarchyFactionQtyMapping[f]++
-		}
+	var nearestRing *dataBuilder.EliteRing
+	if hasRing {
+		nearestRing = &ring
 	}
 
-	for _, count := range nonAnarchyFactionQtyMapping {
-		score += 2 - (1.0 / float32(count))
+	if err := around.loadSources(system, dataStore, config); err != nil {
+		return SystemEvaluationResult{}, nil, err
 	}
 
-	// Do a pre-check to see if it's even worth to do further analysis on this system.
-	return SystemEvaluationResult{
-		AnarchyFactionName:             system.AnarchyFactionNames[0],
-		SystemName:                     system.Name,
-		SourcingFactionsCount:          len(nonAnarchyFactionQtyMapping),
-		SourceSystemAnarchyFactionCount: sourceSystemAnarchyCount,
-		ExternalSystemCount:            outsideSystemCount,
-		ExternalSystemCountWithAnarchy: outsideSystemAnarchyCount,
-		Rings:                          int(system.RingQty),
-		SourcingSystems:                len(populatedSystemsInRange),
-		Score:                          score,
-		MetaSurroundingSystems:         populatedSystemsInRange,
-		MetaSystem:                     system,
-	}, true
-}
+	if len(around.sources) < config.MinSourceSystemCount {
+		return SystemEvaluationResult{}, reject(RejectSourceSystems, len(around.sources), fmt.Sprintf(">= %d", config.MinSourceSystemCount)), nil
+	}
 
-func getAllPopulatedSystemsIn10LyRadius(system dataBuilder.EliteSystem, dataStore map[dataBuilder.EliteSector][]dataBuilder.EliteSystem) []dataBuilder.EliteSystem {
-	returnSystems := make([]dataBuilder.EliteSystem, 0)
+	if around.stationCount < config.MinSourceStationCount {
+		return SystemEvaluationResult{}, reject(RejectSourceStations, around.stationCount, fmt.Sprintf(">= %d", config.MinSourceStationCount)), nil
+	}
 
-	// Get the Systems Sector and all surrounding Sectors
-	ownSector := dataBuilder.BuildSector(system)
-	sectors := buildSectorCube(ownSector)
+	if err := around.loadOutside(system, dataStore, config); err != nil {
+		return SystemEvaluationResult{}, nil, err
+	}
 
-	for _, sector := range sectors {
-		listOfSystemsInSector, hasKey := dataStore[sector]
+	//////////////////// Negative Calculations ///////////////////////////
 
-		if hasKey {
-			returnSystems = appendSystems(system, 10, listOfSystemsInSector, returnSystems)
-		}
+	if around.outsideSystemCount > config.MaxOtherDestSystemsForSource {
+		return SystemEvaluationResult{}, reject(RejectOutsideSystems, around.outsideSystemCount, fmt.Sprintf("<= %d", config.MaxOtherDestSystemsForSource)), nil
 	}
 
-	return returnSystems
-}
+	if around.outsideTargetCount > config.MaxOtherDestSystemsForSourceAnarchyCount {
+		return SystemEvaluationResult{}, reject(RejectOutsideTargets, around.outsideTargetCount, fmt.Sprintf("<= %d", config.MaxOtherDestSystemsForSourceAnarchyCount)), nil
+	}
 
-func appendSystems(systemToIgnore dataBuilder.EliteSystem, maxDistance float32, newSystems []dataBuilder.EliteSystem, oldSystems []dataBuilder.EliteSystem) []dataBuilder.EliteSystem {
-	returnArray := oldSystems
-	maxDistanceSquared := maxDistance * maxDistance
+	//////////////////// Positive Calculations ///////////////////////////
 
-	for _, system := range newSystems {
-		if system.Id == systemToIgnore.Id {
+	// The other target factions of the system take their share of the kills, by their influence relative to the target.
+	siblings := make([]string, 0)
+	siblingWeight := 0.0
+	for _, name := range system.TargetFactionNames {
+		if name == targetName {
 			continue
 		}
-		if sysDistanceSquared(system, systemToIgnore) > maxDistanceSquared {
-			continue
+		siblings = append(siblings, name)
+		sibling, found := system.FindFaction(name)
+		if found && hasTargetFaction && targetFaction.Influence > 0 {
+			siblingWeight += float64(sibling.Influence / targetFaction.Influence)
+		} else {
+			siblingWeight++
 		}
-
-		returnArray = append(returnArray, system)
 	}
 
-	return returnArray
-}
-
-func sysDistanceSquared(a dataBuilder.EliteSystem, b dataBuilder.EliteSystem) float32 {
-	x := a.X - b.X
-	y := a.Y - b.Y
-	z := a.Z - b.Z
+	breakdown := scorer.Score(Metrics{
+		NearestSuitableRing:       nearestRing,
+		RingSuitability:           suitability,
+		GiverFactionBoards:        around.giverFactionBoards,
+		InsideTargetFactionCount:  around.sourceTargetCount,
+		OutsideSystemCount:        around.outsideSystemCount,
+		OutsideTargetFactionCount: around.outsideTargetCount,
+		SiblingTargetWeight:       siblingWeight,
+		Population:                system.Population,
+		PrimaryEconomy:            system.PrimaryEconomy,
+		Government:                system.Government,
+		ControllingPower:          system.ControllingPower,
+	}, config)
+
+	// The givers split their missions among the target factions of the system, again by relative influence.
+	targetShare := 1 / (1 + siblingWeight)
+	stack := estimateStack(around.giverFactionBoards, config.MissionsPerBoard*targetShare, config.KillsPerMission, config.MissionCap)
+	stack.TargetShare = targetShare
 
-	return x*x + y*y + z*z
+	// Do a pre-check to see if it's even worth to do further analysis on this system.
+	return SystemEvaluationResult{
+		TargetFactionName:                targetName,
+		SiblingTargetFactions:            siblings,
+		SystemName:                       system.Name,
+		GiverFactionsCount:               len(around.giverFactionBoards),
+		SourceSystemTargetFactionCount:   around.sourceTargetCount,
+		ExternalSystemCount:              around.outsideSystemCount,
+		ExternalSystemTargetFactionCount: around.outsideTargetCount,
+		Rings:                            int(system.RingQty),
+		NearestSuitableRing:              nearestRing,
+		RingSuitability:                  suitability,
+		Population:                       system.Population,
+		SourcePopulation:                 around.sourcePopulation,
+		ControllingFaction:               system.ControllingFaction,
+		PrimaryEconomy:                   system.PrimaryEconomy,
+		Allegiance:                       system.Allegiance,
+		Government:                       system.Government,
+		ControllingPower:                 system.ControllingPower,
+		PowerState:                       system.PowerState,
+		SourcingSystems:                  len(around.sources),
+		Score:                            breakdown.Total(),
+		ScoreBreakdown:                   breakdown,
+		Stack:                            stack,
+		Payout:                           estimatePayout(stack, config),
+		MetaSurroundingSystems:           around.sources,
+		MetaSystem:                       system,
+	}, nil, nil
 }
 
-func buildSectorCube(sector dataBuilder.EliteSector) []dataBuilder.EliteSector {
-	return []dataBuilder.EliteSector{
-		// Z = -1
-		*dataBuilder.NewEliteSector(sector.X-1, sector.Y-1, sector.Z-1),
-		*dataBuilder.NewEliteSector(sector.X-0, sector.Y-1, sector.Z-1),
-		*dataBuilder.NewEliteSector(sector.X+1, sector.Y-1, sector.Z-1),
-
-		*dataBuilder.NewEliteSector(sector.X-1, sector.Y-0, sector.Z-1),
-		*dataBuilder.NewEliteSector(sector.X-0, sector.Y-0, sector.Z-1),
-		*dataBuilder.NewEliteSector(sector.X+1, sector.Y-0, sector.Z-1),
-
-		*dataBuilder.NewEliteSector(sector.X-1, sector.Y+1, sector.Z-1),
-		*dataBuilder.NewEliteSector(sector.X-0, sector.Y+1, sector.Z-1),
-		*dataBuilder.NewEliteSector(sector.X+1, sector.Y+1, sector.Z-1),
-		// Z = 0
-		*dataBuilder.NewEliteSector(sector.X-1, sector.Y-1, sector.Z),
-		*dataBuilder.NewEliteSector(sector.X-0, sector.Y-1, sector.Z),
-		*dataBuilder.NewEliteSector(sector.X+1, sector.Y-1, sector.Z),
-
-		*dataBuilder.NewEliteSector(sector.X-1, sector.Y-0, sector.Z),
-		sector,
-		*dataBuilder.NewEliteSector(sector.X+1, sector.Y-0, sector.Z),
-
-		*dataBuilder.NewEliteSector(sector.X-1, sector.Y+1, sector.Z),
-		*dataBuilder.NewEliteSector(sector.X-0, sector.Y+1, sector.Z),
-		*dataBuilder.NewEliteSector(sector.X+1, sector.Y+1, sector.Z),
-
-		// Z = 1
-		*dataBuilder.NewEliteSector(sector.X-1, sector.Y-1, sector.Z+1),
-		*dataBuilder.NewEliteSector(sector.X-0, sector.Y-1, sector.Z+1),
-		*dataBuilder.NewEliteSector(sector.X+1, sector.Y-1, sector.Z+1),
-
-		*dataBuilder.NewEliteSector(sector.X-1, sector.Y-0, sector.Z+1),
-		*dataBuilder.NewEliteSector(sector.X-0, sector.Y-0, sector.Z+1),
-		*dataBuilder.NewEliteSector(sector.X+1, sector.Y-0, sector.Z+1),
-
-		*dataBuilder.NewEliteSector(sector.X-1, sector.Y+1, sector.Z+1),
-		*dataBuilder.NewEliteSector(sector.X-0, sector.Y+1, sector.Z+1),
-		*dataBuilder.NewEliteSector(sector.X+1, sector.Y+1, sector.Z+1),
+// matchesSystemFilters checks the system level attributes of a target against the configuration.
+func matchesSystemFilters(system dataBuilder.EliteSystem, config args.Args) *Rejection {
+	if system.Population < config.MinTargetPopulation {
+		return reject(RejectPopulation, system.Population, fmt.Sprintf(">= %d", config.MinTargetPopulation))
 	}
-
+	if config.MaxTargetPopulation > 0 && system.Population > config.MaxTargetPopulation {
+		return reject(RejectPopulation, system.Population, fmt.Sprintf("<= %d", config.MaxTargetPopulation))
+	}
+	if len(config.TargetAllegiances) > 0 && !config.TargetAllegiances.ContainsFold(system.Allegiance) {
+		return reject(RejectAllegiance, system.Allegiance, "one of "+strings.Join(config.TargetAllegiances, ", "))
+	}
+	if len(config.TargetEconomies) > 0 && !config.TargetEconomies.ContainsFold(system.PrimaryEconomy) {
+		return reject(RejectEconomy, system.PrimaryEconomy, "one of "+strings.Join(config.TargetEconomies, ", "))
+	}
+	if len(config.TargetSystemGovernments) > 0 && !config.TargetSystemGovernments.ContainsFold(system.Government) {
+		return reject(RejectGovernment, system.Government, "one of "+strings.Join(config.TargetSystemGovernments, ", "))
+	}
+	excludedPowers := "none of " + strings.Join(config.ExcludeTargetPowers, ", ")
+	if config.ExcludeTargetPowers.ContainsFold(system.ControllingPower) {
+		return reject(RejectPower, system.ControllingPower, excludedPowers)
+	}
+	for _, power := range system.Powers {
+		if config.ExcludeTargetPowers.ContainsFold(power) {
+			return reject(RejectPower, power, excludedPowers)
+		}
+	}
+	return nil
 }
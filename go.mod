@@ -1,9 +1,24 @@
 module massacre-finder
 
-go 1.18
+go 1.21
 
 require (
-	github.com/xxjwxc/gowp v0.0.0-20210520113007-57eb4693b12d // indirect
-	github.com/xxjwxc/public v0.0.0-20210518123934-6cc0965f0bc5 // indirect
-	gopkg.in/eapache/queue.v1 v1.1.0 // indirect
+	github.com/BurntSushi/toml v1.3.2
+	github.com/klauspost/compress v1.17.11
+	gopkg.in/yaml.v3 v3.0.1
+	modernc.org/sqlite v1.34.5
+)
+
+require (
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/kr/pretty v0.2.0 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	golang.org/x/sys v0.22.0 // indirect
+	gopkg.in/check.v1 v1.0.0-20190902080502-41f04d3bba15 // indirect
+	modernc.org/libc v1.55.3 // indirect
+	modernc.org/mathutil v1.6.0 // indirect
+	modernc.org/memory v1.8.0 // indirect
 )
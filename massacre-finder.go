@@ -1,93 +1,128 @@
 package main
 
 import (
-	"encoding/json"
+	"flag"
 	"fmt"
-	"github.com/xxjwxc/gowp/workpool"
-	"io/ioutil"
 	"massacre-finder/args"
 	"massacre-finder/dataBuilder"
-	"massacre-finder/evaluation"
 	"os"
-	"sort"
-	"strconv"
 )
 
-func main() {
-	fmt.Println("Hello World!")
-
-	config := args.Args{
-		FilterOnlyRingedSource:                   true,
-		MinSourceSystemCount:                     3,
-		MaxOtherDestSystemsForSource:             0,
-		MaxOtherDestSystemsForSourceAnarchyCount: 0,
-		MinSourceStationCount:                    6,
-		MaxDistanceInLsForStationToBeConsidered:  1000,
-		ConsiderGroundBases:                      false,
-		ConsiderOdysseySettlements:               false,
-	}
-
-	systemList := dataBuilder.GetOrCreateSystemData("./system_cache.json", "./galaxy_populated.json", false)
-	sectoredData := dataBuilder.BuildSectoredData(systemList, config)
-
-	var semaphore = make(chan int, 1)
-
-	workerPool := workpool.New(10)
+// Exit codes shared by all commands.
+const (
+	exitOK       = 0
+	exitFailure  = 1
+	exitUsage    = 2
+	exitNotFound = 3
+)
 
-	var results = make([]evaluation.SystemEvaluationResult, 100)
+type command struct {
+	name    string
+	summary string
+	run     func(arguments []string) int
+}
 
-	for _, sectorSystems := range sectoredData {
-		for _, system := range sectorSystems {
+var commands = []command{
+	{"build-cache", "parse the galaxy dump into the system cache", runBuildCache},
+	{"update-cache", "apply spansh delta dumps to the system cache", runUpdateCache},
+	{"build-store", "write the system cache into a SQLite store", runBuildStore},
+	{"evaluate", "score all systems and write result.json", runEvaluate},
+	{"inspect", "show the data and evaluation of a single system", runInspect},
+	{"explain", "show which rule rules a system out as target", runExplain},
+	{"simulate", "simulate mission board refreshes to time filling a stack", runSimulate},
+	{"stats", "print a summary of the cached dataset", runStats},
+}
 
-			_system := system
-			workerPool.Do(func() error {
+func main() {
+	if len(os.Args) < 2 {
+		printUsage()
+		os.Exit(exitUsage)
+	}
 
-				response, relevant := evaluation.EvaluateSystem(_system, sectoredData, config)
-				semaphore <- 1
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		printUsage()
+		os.Exit(exitOK)
+	}
 
-				if relevant {
-					results = append(results, response)
-				}
-				<-semaphore
-				return nil
-			})
+	for _, cmd := range commands {
+		if cmd.name == name {
+			os.Exit(cmd.run(os.Args[2:]))
 		}
 	}
 
-	workerPool.Wait() // Wait for all Systems to be parsed
-	fmt.Println("Found " + strconv.Itoa(len(results)) + " Results.")
-	// Sort results
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
-	})
+	fmt.Fprintln(os.Stderr, "unknown command \""+name+"\"")
+	printUsage()
+	os.Exit(exitUsage)
+}
 
-	countToDisplay := len(results)
-	if countToDisplay > 10 {
-		countToDisplay = 10
+func printUsage() {
+	fmt.Fprintln(os.Stderr, "Usage: massacre-finder <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-13s %s\n", cmd.name, cmd.summary)
 	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run \"massacre-finder <command> -h\" for the flags of a command.")
+}
+
+// newFlagSet creates a FlagSet for a command that reports errors instead of exiting.
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flag.ContinueOnError)
+}
 
-	for i, entry := range results[:countToDisplay] {
-		fmt.Println("[", i+1, "]: ", entry.SystemName, " @ ", entry.Score)
+// parseFlags parses the arguments of a command and returns the exit code to use if parsing failed.
+func parseFlags(fs *flag.FlagSet, arguments []string) (int, bool) {
+	if err := fs.Parse(arguments); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK, false
+		}
+		return exitUsage, false
 	}
+	return exitOK, true
+}
 
-	buildAndWriteResult(config, results)
+// dataFlags are the flags every command that needs the system data shares.
+type dataFlags struct {
+	cachePath string
+	source    dataBuilder.Source
+	rebuild   bool
+	storePath string
+}
 
+func (d *dataFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&d.cachePath, "cache", "./system_cache.bin", "path of the system cache")
+	fs.StringVar(&d.source.Path, "source", "./galaxy_populated.json", "path of the spansh galaxy dump used to build the cache, optionally gzip, bzip2 or zstd compressed, - for stdin")
+	fs.StringVar(&d.source.Format, "format", dataBuilder.FormatAuto, "format of the galaxy dump: auto, spansh or edsm")
+	fs.StringVar(&d.source.EDSMStationsPath, "edsm-stations", "", "path of the EDSM stations.json dump, merged into an EDSM source")
+	fs.StringVar(&d.source.EDSMBodiesPath, "edsm-bodies", "", "path of an EDSM bodies dump, merged into an EDSM source for ring data")
+	fs.BoolVar(&d.rebuild, "rebuild", false, "rebuild the cache even if it exists")
 }
 
-type Result struct {
-	Config       args.Args                           `json:"config"`
-	SortedResult []evaluation.SystemEvaluationResult `json:"sortedResult,omitempty"`
+// registerStore adds the -store flag for commands that can work on a SQLite store instead of the cache.
+func (d *dataFlags) registerStore(fs *flag.FlagSet) {
+	fs.StringVar(&d.storePath, "store", "", "path of a SQLite store (see build-store) to use instead of loading the cache into memory")
 }
 
-func buildAndWriteResult(args args.Args, result []evaluation.SystemEvaluationResult) Result {
+func (d *dataFlags) load() ([]dataBuilder.EliteSystemJSON, error) {
+	return dataBuilder.GetOrCreateSystemData(d.cachePath, d.source, d.rebuild)
+}
 
-	returnVal := Result{
-		Config:       args,
-		SortedResult: result,
+// openStore returns the SQLite store if -store is set, otherwise the in-memory store built from the cache.
+// The returned function releases the store.
+func (d *dataFlags) openStore(config args.Args) (dataBuilder.SystemStore, func(), error) {
+	if d.storePath != "" {
+		store, err := dataBuilder.OpenSQLiteStore(d.storePath, config)
+		if err != nil {
+			return nil, nil, err
+		}
+		return store, func() { store.Close() }, nil
 	}
 
-	jsonString, _ := json.MarshalIndent(returnVal, "", "\t")
-
-	ioutil.WriteFile("./result.json", jsonString, os.ModePerm)
-	return returnVal
+	systemList, err := d.load()
+	if err != nil {
+		return nil, nil, err
+	}
+	return dataBuilder.NewMemoryStore(systemList, config), func() {}, nil
 }
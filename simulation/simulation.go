@@ -0,0 +1,154 @@
+// Package simulation plays mission board refreshes around a target through to estimate how long filling a stack takes.
+package simulation
+
+import (
+	"massacre-finder/args"
+	"massacre-finder/dataBuilder"
+	"massacre-finder/evaluation"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Board is the mission board of an eligible station in a source system.
+type Board struct {
+	Station  string
+	System   string
+	Factions []string // giver factions of the system, each may offer a massacre mission per refresh
+	// TargetShare is the chance that such a mission is against the target and not against another target faction
+	// within the mission radius of the station.
+	TargetShare float64
+}
+
+// Distribution summarises the time it took to fill the stack over all runs, in minutes.
+type Distribution struct {
+	Runs   int     `json:"runs"`
+	Filled int     `json:"filled"` // runs that filled the stack within MaxSimulatedHours
+	Mean   float64 `json:"mean"`
+	Min    float64 `json:"min"`
+	P10    float64 `json:"p10"`
+	Median float64 `json:"median"`
+	P90    float64 `json:"p90"`
+	Max    float64 `json:"max"`
+}
+
+// Boards collects the mission boards of the source systems of result. The other target factions around every source
+// system are looked up in store, as missions are spread across all of them.
+func Boards(result evaluation.SystemEvaluationResult, store dataBuilder.SystemStore, config args.Args) ([]Board, error) {
+	radius := float32(config.MissionRadiusLy)
+	boards := make([]Board, 0)
+	for _, source := range result.MetaSurroundingSystems {
+		if len(source.Stations) == 0 || len(source.GiverFactionNames) == 0 {
+			continue
+		}
+
+		neighbours, err := store.SystemsAround(source, radius)
+		if err != nil {
+			return nil, err
+		}
+		targetFactions := int(source.TargetFactionCount)
+		for _, neighbour := range neighbours {
+			targetFactions += int(neighbour.TargetFactionCount)
+		}
+		share := 1.0
+		if targetFactions > 1 {
+			share = 1 / float64(targetFactions)
+		}
+
+		for _, station := range source.Stations {
+			boards = append(boards, Board{
+				Station:     station.Name,
+				System:      source.Name,
+				Factions:    source.GiverFactionNames,
+				TargetShare: share,
+			})
+		}
+	}
+	return boards, nil
+}
+
+// Simulate fills a stack of MissionCap missions runs times. The player flies from board to board in order, taking
+// TravelMinutesPerStation per hop, and takes every mission against the target on a board that refreshed since the
+// last visit. Boards refresh every BoardRefreshMinutes, each at its own random offset.
+func Simulate(boards []Board, config args.Args, runs int, rng *rand.Rand) Distribution {
+	distribution := Distribution{Runs: runs}
+	if len(boards) == 0 {
+		return distribution
+	}
+
+	probabilities := make([][]float64, len(boards))
+	for i, board := range boards {
+		probabilities[i] = make([]float64, len(board.Factions))
+		for j, faction := range board.Factions {
+			probability, found := config.FactionMissionProbabilities.Lookup(faction)
+			if !found {
+				probability = config.MassacreMissionProbability
+			}
+			probabilities[i][j] = probability * board.TargetShare
+		}
+	}
+
+	times := make([]float64, 0, runs)
+	for run := 0; run < runs; run++ {
+		if minutes, filled := simulateRun(probabilities, config, rng); filled {
+			times = append(times, minutes)
+		}
+	}
+
+	distribution.Filled = len(times)
+	if len(times) == 0 {
+		return distribution
+	}
+	sort.Float64s(times)
+	sum := 0.0
+	for _, minutes := range times {
+		sum += minutes
+	}
+	distribution.Mean = sum / float64(len(times))
+	distribution.Min = times[0]
+	distribution.P10 = percentile(times, 0.1)
+	distribution.Median = percentile(times, 0.5)
+	distribution.P90 = percentile(times, 0.9)
+	distribution.Max = times[len(times)-1]
+	return distribution
+}
+
+// simulateRun returns the minutes it took to fill the stack, probabilities holds the chance per board and faction
+// of a mission against the target in a refresh.
+func simulateRun(probabilities [][]float64, config args.Args, rng *rand.Rand) (float64, bool) {
+	refresh := config.BoardRefreshMinutes
+	offsets := make([]float64, len(probabilities))
+	lastSeen := make([]int, len(probabilities))
+	for i := range probabilities {
+		offsets[i] = rng.Float64() * refresh
+		lastSeen[i] = -1
+	}
+
+	missions := 0
+	maxMinutes := config.MaxSimulatedHours * 60
+	for minutes, board := 0.0, 0; minutes <= maxMinutes; minutes, board = minutes+config.TravelMinutesPerStation, (board+1)%len(probabilities) {
+		epoch := int(math.Floor((minutes + offsets[board]) / refresh))
+		if epoch == lastSeen[board] {
+			continue // nothing new since the last visit
+		}
+		lastSeen[board] = epoch
+
+		for _, probability := range probabilities[board] {
+			if rng.Float64() < probability {
+				missions++
+				if missions >= config.MissionCap {
+					return minutes, true
+				}
+			}
+		}
+	}
+	return 0, false
+}
+
+func percentile(sorted []float64, p float64) float64 {
+	index := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if index < 0 {
+		index = 0
+	}
+	return sorted[index]
+}